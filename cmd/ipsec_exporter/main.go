@@ -1,9 +1,11 @@
 package main
 
 import (
+	"fmt"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/go-kit/kit/log/level"
 	"github.com/google/shlex"
@@ -36,45 +38,112 @@ func newCmd(s kingpin.Settings) (target *[]string) {
 func main() {
 	var (
 		address       = kingpin.Flag("vici.address", "VICI socket address.").PlaceHolder(`"` + viciDefaultAddress + `"`).Default(viciDefaultAddress).URL()
-		timeout       = kingpin.Flag("vici.timeout", "VICI socket connect timeout.").Default("1s").Duration()
-		collector     = kingpin.Flag("collector", "Collector type to scrape metrics with. One of: [vici, ipsec]").Default("vici").Enum("vici", "ipsec")
+		collector     = kingpin.Flag("collector", "Collector type to scrape metrics with. One of: [vici, ipsec, whack, stroke]").Default("vici").Enum("vici", "ipsec", "whack", "stroke")
+		timeout       = kingpin.Flag("collector.timeout", "Timeout of the VICI, whack and stroke socket operations and of the libreswan optional commands.").Default("1s").Duration()
+		whackSocket   = kingpin.Flag("whack.socket", "pluto control socket path.").Default("/run/pluto/pluto.ctl").String()
+		strokeSocket  = kingpin.Flag("stroke.socket", "charon stroke socket path.").Default("/var/run/charon.ctl").String()
 		ipsecCmd      = newCmd(kingpin.Flag("ipsec.command", "Command to scrape IPsec metrics from.").PlaceHolder(`"ipsec statusall"`).Default("ipsec statusall"))
+		trafficCmd    = newCmd(kingpin.Flag("libreswan.trafficstatus-command", "Command to merge libreswan traffic status from. Disabled by default.").PlaceHolder(`"ipsec whack --trafficstatus"`))
+		poolCmd       = newCmd(kingpin.Flag("libreswan.addresspoolstatus-command", "Command to get libreswan address pools from. Disabled by default.").PlaceHolder(`"ipsec whack --addresspoolstatus"`))
+		globalCmd     = newCmd(kingpin.Flag("libreswan.globalstatus-command", "Command to get libreswan global state counts from. Disabled by default.").PlaceHolder(`"ipsec whack --globalstatus"`))
+		timezone      = kingpin.Flag("daemon.timezone", "Timezone the IPsec daemon reports its start time in.").Default("Local").String()
+		pidFile       = kingpin.Flag("libreswan.pid-file", "pluto pid file to get the libreswan daemon start time from.").Default("/run/pluto/pluto.pid").String()
+		procFS        = kingpin.Flag("path.procfs", "procfs mountpoint to get the libreswan daemon start time from.").Default("/proc").String()
+		tunnelsFile   = kingpin.Flag("tunnels.config-file", "YAML file with tunnels expected to be up. Disabled by default.").String()
 		webConfig     = webflag.AddFlags(kingpin.CommandLine)
 		listenAddress = kingpin.Flag("web.listen-address", "Address to listen on for web interface and telemetry.").Default(":9903").String()
 		metricsPath   = kingpin.Flag("web.telemetry-path", "Path under which to expose metrics.").Default("/metrics").String()
+		statusPath    = kingpin.Flag("web.status-path", "Path under which to expose the JSON status.").Default("/api/v1/status").String()
+
+		_           = kingpin.Command("serve", "Run the exporter.").Default()
+		checkCmd    = kingpin.Command("check", "Scrape once and print a Nagios plugin compatible status.")
+		checkConns  = checkCmd.Flag("check.connection", "Connection that must be established. Can be repeated.").Strings()
+		checkChilds = checkCmd.Flag("check.min-child-sas", "Minimum number of installed child SAs per connection.").Default("1").Int()
+		checkPoolW  = checkCmd.Flag("check.pool-warning", "Pool usage percent to warn at. Disabled by default.").Default("0").Float64()
+		checkPoolC  = checkCmd.Flag("check.pool-critical", "Pool usage percent to be critical at. Disabled by default.").Default("0").Float64()
+		checkCerts  = checkCmd.Flag("check.cert-file", "PEM or DER encoded certificate to check the expiry of. Can be repeated.").Strings()
+		checkCertW  = checkCmd.Flag("check.cert-warning", "Time left before the certificate expiry to warn at.").Default("720h").Duration()
+		checkCertC  = checkCmd.Flag("check.cert-critical", "Time left before the certificate expiry to be critical at.").Default("168h").Duration()
 	)
+	// The timeout used to be VICI only
+	kingpin.Flag("vici.timeout", "Alias of --collector.timeout.").Hidden().DurationVar(timeout)
 	promlogConfig := &promlog.Config{}
 	flag.AddFlags(kingpin.CommandLine, promlogConfig)
 	kingpin.HelpFlag.Short('h')
 	kingpin.Version(version.Print("ipsec_exporter"))
-	kingpin.Parse()
+	command := kingpin.Parse()
 	logger := promlog.New(promlogConfig)
+	// Nagios treats 1 as a warning
+	exitCode := 1
+	if command == checkCmd.FullCommand() {
+		exitCode = exporter.CheckUnknown
+	}
 
-	level.Info(logger).Log("msg", "Starting ipsec_exporter", "version", version.Info())
-	level.Info(logger).Log("msg", "Build context", "context", version.BuildContext())
-
-	prometheus.MustRegister(version.NewCollector("ipsec_exporter"))
 	collectorType := exporter.CollectorVICI
-	if *collector == "ipsec" {
+	switch *collector {
+	case "ipsec":
 		collectorType = exporter.CollectorIpsec
+	case "whack":
+		collectorType = exporter.CollectorWhack
+	case "stroke":
+		collectorType = exporter.CollectorStroke
+	}
+	location, err := time.LoadLocation(*timezone)
+	if err != nil {
+		level.Error(logger).Log("msg", "Error loading the daemon timezone", "err", err)
+		os.Exit(exitCode)
+	}
+	var tunnels []exporter.Tunnel
+	if *tunnelsFile != "" {
+		if tunnels, err = exporter.LoadTunnels(*tunnelsFile); err != nil {
+			level.Error(logger).Log("msg", "Error loading expected tunnels", "file", *tunnelsFile, "err", err)
+			os.Exit(exitCode)
+		}
 	}
-	exporter, err := exporter.New(collectorType, *address, *timeout, *ipsecCmd, logger)
+	checkConfig := exporter.CheckConfig{
+		Connections:  *checkConns,
+		MinChildSAs:  *checkChilds,
+		PoolWarning:  *checkPoolW,
+		PoolCritical: *checkPoolC,
+		CertFiles:    *checkCerts,
+		CertWarning:  *checkCertW,
+		CertCritical: *checkCertC,
+	}
+	exporter, err := exporter.New(
+		collectorType,
+		*address,
+		*timeout,
+		*ipsecCmd,
+		logger,
+		exporter.WithWhackSocket(*whackSocket),
+		exporter.WithStrokeSocket(*strokeSocket),
+		exporter.WithTrafficStatusCommand(*trafficCmd),
+		exporter.WithAddressPoolStatusCommand(*poolCmd),
+		exporter.WithGlobalStatusCommand(*globalCmd),
+		exporter.WithLocation(location),
+		exporter.WithPlutoPIDFile(*pidFile),
+		exporter.WithProcFS(*procFS),
+		exporter.WithExpectedTunnels(tunnels),
+	)
 	if err != nil {
 		level.Error(logger).Log("msg", "Error creating the exporter", "err", err)
-		os.Exit(1)
+		os.Exit(exitCode)
 	}
+	if command == checkCmd.FullCommand() {
+		result := exporter.Check(checkConfig)
+		fmt.Println(result)
+		os.Exit(result.Status)
+	}
+
+	level.Info(logger).Log("msg", "Starting ipsec_exporter", "version", version.Info())
+	level.Info(logger).Log("msg", "Build context", "context", version.BuildContext())
+
+	prometheus.MustRegister(version.NewCollector("ipsec_exporter"))
 	prometheus.MustRegister(exporter)
 
 	http.Handle(*metricsPath, promhttp.Handler())
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte(`<html>
-             <head><title>IPsec Exporter</title></head>
-             <body>
-             <h1>IPsec Exporter</h1>
-             <p><a href='` + *metricsPath + `'>Metrics</a></p>
-             </body>
-             </html>`))
-	})
+	http.Handle(*statusPath, exporter.StatusHandler())
+	http.Handle("/", exporter.DashboardHandler(*metricsPath, *statusPath))
 
 	level.Info(logger).Log("msg", "Listening on address", "address", *listenAddress)
 	srv := &http.Server{Addr: *listenAddress}
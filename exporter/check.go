@@ -0,0 +1,204 @@
+package exporter
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Check statuses, the same as Nagios plugin exit codes.
+const (
+	CheckOK = iota
+	CheckWarning
+	CheckCritical
+	CheckUnknown
+)
+
+var checkStatusNames = []string{"OK", "WARNING", "CRITICAL", "UNKNOWN"}
+
+// checkSeverities orders the check statuses from the least severe.
+var checkSeverities = []int{CheckOK, CheckUnknown, CheckWarning, CheckCritical}
+
+// CheckConfig configures the conditions Check evaluates.
+type CheckConfig struct {
+	// Connections must have an established IKE SA with at least MinChildSAs
+	// installed child SAs.
+	Connections []string
+	MinChildSAs int
+
+	// PoolWarning and PoolCritical are pool usage thresholds in percent,
+	// disabled if 0.
+	PoolWarning  float64
+	PoolCritical float64
+
+	// CertFiles are PEM or DER encoded certificates to check the expiry of
+	// against the CertWarning and CertCritical thresholds.
+	CertFiles    []string
+	CertWarning  time.Duration
+	CertCritical time.Duration
+}
+
+// CheckResult is the result of Check.
+type CheckResult struct {
+	Status   int
+	Messages []string
+	PerfData []string
+}
+
+func (r *CheckResult) add(status int, format string, a ...interface{}) {
+	if severity(status) > severity(r.Status) {
+		r.Status = status
+	}
+	r.Messages = append(r.Messages, fmt.Sprintf(format, a...))
+}
+
+func (r *CheckResult) addPerfData(label string, value interface{}, uom, warn, crit, min, max string) {
+	label = strings.Replace(label, "'", "_", -1)
+	r.PerfData = append(r.PerfData, strings.TrimRight(fmt.Sprintf("'%s'=%v%s;%s;%s;%s;%s", label, value, uom, warn, crit, min, max), ";"))
+}
+
+// String returns the result as a Nagios plugin output line.
+func (r CheckResult) String() string {
+	s := "IPSEC " + checkStatusNames[r.Status]
+	if len(r.Messages) > 0 {
+		s += " - " + strings.Join(r.Messages, ", ")
+	}
+	if len(r.PerfData) > 0 {
+		s += " | " + strings.Join(r.PerfData, " ")
+	}
+	return s
+}
+
+func severity(status int) int {
+	for i, s := range checkSeverities {
+		if s == status {
+			return i
+		}
+	}
+	return 0
+}
+
+// Check scrapes the daemon once and evaluates the conditions.
+func (e *Exporter) Check(cfg CheckConfig) CheckResult {
+	var r CheckResult
+	m, ok := e.scrape(e)
+	if !ok {
+		r.add(CheckUnknown, "failed to scrape the daemon status")
+		return r
+	}
+	r.addPerfData("ike_sas", len(m.IKESAs), "", "", "", "0", "")
+	for _, name := range cfg.Connections {
+		established, installed := false, 0
+		for _, ikeSA := range m.IKESAs {
+			if ikeSA.ConnectionName() != name || !isUp(statusOf(ikeSAStatuses, ikeSA.State)) {
+				continue
+			}
+			established = true
+			for _, childSA := range ikeSA.ChildSAs {
+				if isUp(statusOf(childSAStatuses, childSA.State)) {
+					installed++
+				}
+			}
+		}
+		switch {
+		case !established:
+			r.add(CheckCritical, "connection %s is not established", name)
+		case installed < cfg.MinChildSAs:
+			r.add(CheckCritical, "connection %s has %d of %d child SAs installed", name, installed, cfg.MinChildSAs)
+		}
+		r.addPerfData(name+"_child_sas", installed, "", "", fmt.Sprintf("%d:", cfg.MinChildSAs), "0", "")
+	}
+	for _, pool := range m.Pools {
+		if pool.Size == 0 {
+			continue
+		}
+		usage := float64(pool.Online) * 100 / float64(pool.Size)
+		switch {
+		case cfg.PoolCritical > 0 && usage >= cfg.PoolCritical:
+			r.add(CheckCritical, "pool %s is %.0f%% used", pool.Name, usage)
+		case cfg.PoolWarning > 0 && usage >= cfg.PoolWarning:
+			r.add(CheckWarning, "pool %s is %.0f%% used", pool.Name, usage)
+		}
+		r.addPerfData("pool_"+pool.Name, math.Round(usage), "%", formatThreshold(cfg.PoolWarning), formatThreshold(cfg.PoolCritical), "0", "100")
+	}
+	for _, path := range cfg.CertFiles {
+		notAfter, err := certNotAfter(path)
+		if err != nil {
+			r.add(CheckUnknown, "failed to read certificate %s: %v", path, err)
+			continue
+		}
+		left := notAfter.Sub(now())
+		switch {
+		case left <= 0:
+			r.add(CheckCritical, "certificate %s expired on %s", path, notAfter.Format(time.RFC3339))
+		case left < cfg.CertCritical:
+			r.add(CheckCritical, "certificate %s expires on %s", path, notAfter.Format(time.RFC3339))
+		case left < cfg.CertWarning:
+			r.add(CheckWarning, "certificate %s expires on %s", path, notAfter.Format(time.RFC3339))
+		}
+		r.addPerfData("cert_"+filepath.Base(path)+"_days", math.Floor(left.Hours()/24), "", formatDays(cfg.CertWarning), formatDays(cfg.CertCritical), "", "")
+	}
+	if len(r.Messages) == 0 {
+		r.add(CheckOK, "%d IKE SAs", len(m.IKESAs))
+	}
+	return r
+}
+
+func statusOf(statuses map[string]float64, state string) float64 {
+	if status, ok := statuses[state]; ok {
+		return status
+	}
+	return math.NaN()
+}
+
+func formatThreshold(f float64) string {
+	if f == 0 {
+		return ""
+	}
+	return fmt.Sprint(f)
+}
+
+func formatDays(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.0f:", math.Ceil(d.Hours()/24))
+}
+
+// certNotAfter returns the earliest expiry time of the certificates
+// in the file.
+func certNotAfter(path string) (notAfter time.Time, err error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var ders [][]byte
+	for {
+		var block *pem.Block
+		if block, b = pem.Decode(b); block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			ders = append(ders, block.Bytes)
+		}
+	}
+	if ders == nil {
+		// Not PEM, assume DER as in /etc/ipsec.d/certs
+		ders = [][]byte{b}
+	}
+	for _, der := range ders {
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if notAfter.IsZero() || cert.NotAfter.Before(notAfter) {
+			notAfter = cert.NotAfter
+		}
+	}
+	return
+}
@@ -0,0 +1,146 @@
+package exporter
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+func TestExporter_Check(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ipsec_exporter")
+	if err != nil {
+		panic("failed to create temp dir: " + err.Error())
+	}
+	defer os.RemoveAll(dir)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic("failed to generate key: " + err.Error())
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "moon"},
+		NotBefore:    now().Add(-24 * time.Hour),
+		NotAfter:     now().Add(10 * 24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		panic("failed to create certificate: " + err.Error())
+	}
+	pemCert := filepath.Join(dir, "moonCert.pem")
+	if err := ioutil.WriteFile(pemCert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0666); err != nil {
+		panic("failed to write " + pemCert + ": " + err.Error())
+	}
+	derCert := filepath.Join(dir, "moonCert.der")
+	if err := ioutil.WriteFile(derCert, der, 0666); err != nil {
+		panic("failed to write " + derCert + ": " + err.Error())
+	}
+
+	exporter, err := New(CollectorIpsec, nil, 0, nil, log.NewNopLogger())
+	if err != nil {
+		t.Fatalf("New() = _, %v; want nil", err)
+	}
+	exporter.scrape = func(e *Exporter) (m metrics, ok bool) {
+		return metrics{
+			Pools: []pool{
+				{Name: "pool", Address: "10.0.4.0/24", Size: 100, Online: 85},
+			},
+			IKESAs: []*ikeSA{
+				{
+					Name:  "named[1]",
+					State: "STATE_V2_ESTABLISHED_IKE_SA",
+					ChildSAs: map[string]*childSA{
+						"named-2": {Name: "named", State: "STATE_V2_ESTABLISHED_CHILD_SA"},
+					},
+				},
+				{Name: "connecting", State: "STATE_V2_PARENT_I1"},
+			},
+		}, true
+	}
+	tests := []struct {
+		name string
+		cfg  CheckConfig
+		want string
+		code int
+	}{
+		{
+			name: "ok",
+			cfg:  CheckConfig{Connections: []string{"named"}, MinChildSAs: 1},
+			want: "IPSEC OK - 2 IKE SAs | 'ike_sas'=2;;;0 'named_child_sas'=1;;1:;0 'pool_pool'=85%;;;0;100",
+			code: CheckOK,
+		},
+		{
+			name: "not established",
+			cfg:  CheckConfig{Connections: []string{"named", "connecting"}, MinChildSAs: 1},
+			want: "IPSEC CRITICAL - connection connecting is not established | 'ike_sas'=2;;;0 'named_child_sas'=1;;1:;0 'connecting_child_sas'=0;;1:;0 'pool_pool'=85%;;;0;100",
+			code: CheckCritical,
+		},
+		{
+			name: "not enough child SAs",
+			cfg:  CheckConfig{Connections: []string{"named"}, MinChildSAs: 2},
+			want: "IPSEC CRITICAL - connection named has 1 of 2 child SAs installed | 'ike_sas'=2;;;0 'named_child_sas'=1;;2:;0 'pool_pool'=85%;;;0;100",
+			code: CheckCritical,
+		},
+		{
+			name: "pool warning",
+			cfg:  CheckConfig{PoolWarning: 80, PoolCritical: 90},
+			want: "IPSEC WARNING - pool pool is 85% used | 'ike_sas'=2;;;0 'pool_pool'=85%;80;90;0;100",
+			code: CheckWarning,
+		},
+		{
+			name: "pool critical",
+			cfg:  CheckConfig{PoolWarning: 70, PoolCritical: 80},
+			want: "IPSEC CRITICAL - pool pool is 85% used | 'ike_sas'=2;;;0 'pool_pool'=85%;70;80;0;100",
+			code: CheckCritical,
+		},
+		{
+			name: "cert ok",
+			cfg:  CheckConfig{CertFiles: []string{pemCert, derCert}, CertWarning: 7 * 24 * time.Hour, CertCritical: 24 * time.Hour},
+			want: "IPSEC OK - 2 IKE SAs | 'ike_sas'=2;;;0 'pool_pool'=85%;;;0;100 'cert_moonCert.pem_days'=10;7:;1: 'cert_moonCert.der_days'=10;7:;1:",
+			code: CheckOK,
+		},
+		{
+			name: "cert warning",
+			cfg:  CheckConfig{CertFiles: []string{pemCert}, CertWarning: 30 * 24 * time.Hour, CertCritical: 7 * 24 * time.Hour},
+			want: "IPSEC WARNING - certificate " + pemCert + " expires on 1970-01-11T00:00:00Z | 'ike_sas'=2;;;0 'pool_pool'=85%;;;0;100 'cert_moonCert.pem_days'=10;30:;7:",
+			code: CheckWarning,
+		},
+		{
+			name: "cert missing",
+			cfg:  CheckConfig{CertFiles: []string{filepath.Join(dir, "missing.pem")}, PoolWarning: 80},
+			want: "IPSEC WARNING - pool pool is 85% used, failed to read certificate " + filepath.Join(dir, "missing.pem") + ": open " + filepath.Join(dir, "missing.pem") + ": no such file or directory | 'ike_sas'=2;;;0 'pool_pool'=85%;80;;0;100",
+			code: CheckWarning,
+		},
+	}
+	for _, td := range tests {
+		t.Run(td.name, func(t *testing.T) {
+			r := exporter.Check(td.cfg)
+			if got := r.String(); got != td.want {
+				t.Errorf("Check().String() = %q; want %q", got, td.want)
+			}
+			if r.Status != td.code {
+				t.Errorf("Check().Status = %d; want %d", r.Status, td.code)
+			}
+		})
+	}
+
+	exporter.scrape = func(e *Exporter) (m metrics, ok bool) { return }
+	r := exporter.Check(CheckConfig{})
+	want := "IPSEC UNKNOWN - failed to scrape the daemon status"
+	if got := r.String(); got != want {
+		t.Errorf("Check().String() = %q; want %q", got, want)
+	}
+	if r.Status != CheckUnknown {
+		t.Errorf("Check().Status = %d; want %d", r.Status, CheckUnknown)
+	}
+}
@@ -0,0 +1,184 @@
+package exporter
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+)
+
+var dashboardTmpl = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"age":       formatAge,
+	"bytes":     formatBytes,
+	"percent":   formatPercent,
+	"join":      strings.Join,
+	"ikeSAUp":   func(state string) bool { return isUp(ikeSAStatuses[state]) },
+	"childSAUp": func(state string) bool { return isUp(childSAStatuses[state]) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>IPsec Exporter</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.5em; text-align: left; vertical-align: top; }
+.up { color: #080; }
+.down { color: #c00; }
+</style>
+</head>
+<body>
+<h1>IPsec Exporter</h1>
+<p><a href="{{.MetricsPath}}">Metrics</a> &middot; <a href="{{.StatusPath}}">Status API</a></p>
+{{- if .Failed}}
+<p class="down">Failed to scrape the daemon status.</p>
+{{- else}}
+<h2>Daemon</h2>
+<table>
+<tr><th>Implementation</th><td>{{.Daemon.Implementation}} {{.Daemon.Version}}</td></tr>
+{{- if .Daemon.SysName}}
+<tr><th>System</th><td>{{.Daemon.SysName}} {{.Daemon.Release}} {{.Daemon.Machine}}</td></tr>
+{{- end}}
+{{- if .Started}}
+<tr><th>Started</th><td>{{.Started.Format "2006-01-02 15:04:05 MST"}}</td></tr>
+{{- end}}
+<tr><th>IKE SAs</th><td>{{.Stats.IKESAs.Total}} ({{.Stats.IKESAs.HalfOpen}} half-open)</td></tr>
+</table>
+{{- if .Pools}}
+<h2>Pools</h2>
+<table>
+<tr><th>Name</th><th>Address</th><th>Size</th><th>Online</th><th>Offline</th><th>Utilisation</th></tr>
+{{- range .Pools}}
+<tr><td>{{.Name}}</td><td>{{.Address}}</td><td>{{.Size}}</td><td>{{.Online}}</td><td>{{.Offline}}</td><td>{{percent .Online .Size}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+<h2>SAs</h2>
+{{- if .Rows}}
+<table>
+<tr><th>IKE SA</th><th>State</th><th>Age</th><th>Local</th><th>Remote</th><th>Child SA</th><th>State</th><th>Age</th><th>In</th><th>Out</th><th>Local TS</th><th>Remote TS</th></tr>
+{{- range .Rows}}
+<tr>
+{{- with .IKESA}}
+<td rowspan="{{$.RowSpan .}}">{{.Name}}</td>
+<td rowspan="{{$.RowSpan .}}" class="{{if ikeSAUp .State}}up{{else}}down{{end}}">{{.State}}</td>
+<td rowspan="{{$.RowSpan .}}">{{age .Established}}</td>
+<td rowspan="{{$.RowSpan .}}">{{.LocalHost}}{{if .LocalID}}<br>{{.LocalID}}{{end}}</td>
+<td rowspan="{{$.RowSpan .}}">{{.RemoteHost}}{{if .RemoteID}}<br>{{.RemoteID}}{{end}}</td>
+{{- end}}
+{{- with .ChildSA}}
+<td>{{.Name}}</td>
+<td class="{{if childSAUp .State}}up{{else}}down{{end}}">{{.State}}</td>
+<td>{{age .Installed}}</td>
+<td>{{bytes .InBytes}}</td>
+<td>{{bytes .OutBytes}}</td>
+<td>{{join .LocalTS ", "}}</td>
+<td>{{join .RemoteTS ", "}}</td>
+{{- else}}
+<td colspan="7"></td>
+{{- end}}
+</tr>
+{{- end}}
+</table>
+{{- else}}
+<p>No IKE SAs.</p>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
+
+// dashboardRow is a row of the SA table. IKESA is only set in the first row
+// of the IKE SA and ChildSA is nil if the IKE SA has no child SAs.
+type dashboardRow struct {
+	IKESA   *ikeSA
+	ChildSA *childSA
+}
+
+type dashboard struct {
+	metrics
+	MetricsPath string
+	StatusPath  string
+	Failed      bool
+	Started     *time.Time
+	Rows        []dashboardRow
+}
+
+// RowSpan returns the number of table rows the IKE SA spans.
+func (d *dashboard) RowSpan(sa *ikeSA) int {
+	if len(sa.ChildSAs) == 0 {
+		return 1
+	}
+	return len(sa.ChildSAs)
+}
+
+// DashboardHandler returns a handler serving an HTML page with the scraped
+// daemon status, pools and SAs linking to the metrics and status paths.
+// A failed scrape is shown on the page without failing the request as the
+// page is usually the landing one.
+func (e *Exporter) DashboardHandler(metricsPath, statusPath string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		m, ok := e.scrape(e)
+		d := &dashboard{metrics: m, MetricsPath: metricsPath, StatusPath: statusPath, Failed: !ok}
+		if started, _, ok := e.startTime(m.Stats.Uptime); ok {
+			d.Started = &started
+		}
+		for _, ikeSA := range m.IKESAs {
+			names := make([]string, 0, len(ikeSA.ChildSAs))
+			for name := range ikeSA.ChildSAs {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			row := dashboardRow{IKESA: ikeSA}
+			for _, name := range names {
+				row.ChildSA = ikeSA.ChildSAs[name]
+				d.Rows = append(d.Rows, row)
+				row = dashboardRow{}
+			}
+			if len(names) == 0 {
+				d.Rows = append(d.Rows, row)
+			}
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := dashboardTmpl.Execute(w, d); err != nil {
+			level.Error(e.logger).Log("msg", "Failed to render dashboard", "err", err)
+		}
+	})
+}
+
+// formatAge formats the number of seconds since an event or returns
+// an empty string if it's unknown.
+func formatAge(sec *int64) string {
+	if sec == nil {
+		return ""
+	}
+	return (time.Duration(*sec) * time.Second).String()
+}
+
+var byteUnits = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+
+func formatBytes(n uint64) string {
+	f, unit := float64(n), 0
+	for f >= 1024 && unit < len(byteUnits)-1 {
+		f /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", n)
+	}
+	return fmt.Sprintf("%.1f %s", f, byteUnits[unit])
+}
+
+func formatPercent(n, total uint64) string {
+	if total == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.0f%%", float64(n)*100/float64(total))
+}
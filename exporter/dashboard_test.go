@@ -0,0 +1,82 @@
+package exporter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+)
+
+func TestExporter_DashboardHandler(t *testing.T) {
+	exporter, err := New(CollectorIpsec, nil, 0, nil, log.NewNopLogger())
+	if err != nil {
+		t.Fatalf("New() = _, %v; want nil", err)
+	}
+	exporter.scrape = func(e *Exporter) (m metrics, ok bool) {
+		sec := int64(3723)
+		return metrics{
+			Daemon: daemon{
+				Implementation: "strongswan",
+				Version:        "5.9.1",
+			},
+			Pools: []pool{
+				{Name: "pool", Address: "10.0.4.0/24", Size: 254, Online: 127},
+			},
+			IKESAs: []*ikeSA{
+				{
+					Name:        "named",
+					State:       "ESTABLISHED",
+					RemoteHost:  "10.0.3.1",
+					RemoteID:    "<script>",
+					Established: &sec,
+					ChildSAs: map[string]*childSA{
+						"named-2": {Name: "named", State: "INSTALLED", InBytes: 2048, LocalTS: []string{"10.0.1.0/24"}},
+						"named-1": {Name: "named", State: "REKEYED", OutBytes: 100},
+					},
+				},
+				{Name: "connecting", State: "CONNECTING"},
+			},
+		}, true
+	}
+	rec := httptest.NewRecorder()
+	exporter.DashboardHandler("/metrics", "/status").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("DashboardHandler() code = %d; want %d", rec.Code, http.StatusOK)
+	}
+	body := rec.Body.String()
+	for _, s := range []string{
+		`<a href="/metrics">Metrics</a>`,
+		`<a href="/status">Status API</a>`,
+		`<td>strongswan 5.9.1</td>`,
+		`<td>127</td><td>0</td><td>50%</td>`,
+		`<td rowspan="2">named</td>`,
+		`<td rowspan="2" class="up">ESTABLISHED</td>`,
+		`<td rowspan="2">1h2m3s</td>`,
+		`10.0.3.1<br>&lt;script&gt;`,
+		`<td class="down">REKEYED</td>`,
+		`<td>100 B</td>`,
+		`<td>2.0 KiB</td>`,
+		`<td>10.0.1.0/24</td>`,
+		`<td rowspan="1" class="down">CONNECTING</td>`,
+		`<td colspan="7"></td>`,
+	} {
+		if !strings.Contains(body, s) {
+			t.Errorf("DashboardHandler() body doesn't contain %q:\n%s", s, body)
+		}
+	}
+	if i, j := strings.Index(body, "REKEYED"), strings.Index(body, "INSTALLED"); i > j {
+		t.Errorf("DashboardHandler() child SAs aren't sorted:\n%s", body)
+	}
+
+	exporter.scrape = func(e *Exporter) (m metrics, ok bool) { return }
+	rec = httptest.NewRecorder()
+	exporter.DashboardHandler("/metrics", "/status").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("DashboardHandler() code = %d; want %d", rec.Code, http.StatusOK)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "Failed to scrape") {
+		t.Errorf("DashboardHandler() body doesn't contain the error:\n%s", body)
+	}
+}
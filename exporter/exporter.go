@@ -2,6 +2,8 @@
 package exporter
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"math"
 	"net/url"
@@ -21,6 +23,8 @@ import (
 const (
 	CollectorVICI = iota
 	CollectorIpsec
+	CollectorWhack
+	CollectorStroke
 )
 
 const namespace = "ipsec"
@@ -28,6 +32,11 @@ const namespace = "ipsec"
 var (
 	reSSMarker = regexp.MustCompile(`(?m)` + ssSAHeaderRE.String())
 	reLSMarker = regexp.MustCompile(`(?m)` + lsPrefix + `Connection list:$`)
+	reSWMarker = regexp.MustCompile(`(?m)(?:` + swUptimeRE.String() + `|` + swSAHeaderRE.String() + `)`)
+
+	// reSWCommand matches swanctl commands which output has no marker
+	// if there is nothing to list.
+	reSWCommand = regexp.MustCompile(`(?:^|[\s/])swanctl(?:\s|$)`)
 )
 
 var (
@@ -35,6 +44,7 @@ var (
 		"name",
 		"uid",
 		"version",
+		"role",
 		"local_host",
 		"local_id",
 		"remote_host",
@@ -42,10 +52,23 @@ var (
 		"remote_identity",
 		"vips",
 	}
+	connectionLbls = []string{
+		"name",
+		"version",
+		"local_host",
+		"local_id",
+		"remote_host",
+		"remote_id",
+		"local_auth",
+		"remote_auth",
+		"routing",
+		"policy",
+	}
 	childSALbls = []string{
 		"ike_sa_name",
 		"ike_sa_uid",
 		"ike_sa_version",
+		"ike_sa_role",
 		"ike_sa_local_host",
 		"ike_sa_local_id",
 		"ike_sa_remote_host",
@@ -66,6 +89,26 @@ var (
 	childSAStates = make(map[string]float64)
 )
 
+// SA statuses, the same for all the implementations.
+const (
+	statusCreated = iota
+	statusConnecting
+	statusEstablished
+	statusRekeying
+	statusDeleting
+)
+
+var (
+	ikeSAStatuses   = make(map[string]float64)
+	childSAStatuses = make(map[string]float64)
+)
+
+// Known IKE and child SA states by implementation.
+var (
+	ikeSAKnownStates   = make(map[string]map[string]float64)
+	childSAKnownStates = make(map[string]map[string]float64)
+)
+
 var (
 	now = time.Now
 	tz  = time.Local
@@ -74,56 +117,153 @@ var (
 // Exporter collects IPsec stats via a VICI protocol or an ipsec binary
 // and exports them using the prometheus metrics package.
 type Exporter struct {
-	scrape   func(e *Exporter) (m metrics, ok bool)
-	address  *url.URL
-	timeout  time.Duration
-	ipsecCmd []string
-	logger   log.Logger
-	mu       sync.Mutex
+	scrape       func(e *Exporter) (m metrics, ok bool)
+	address      *url.URL
+	timeout      time.Duration
+	ipsecCmd     []string
+	whackSocket  string
+	strokeSocket string
+	location     *time.Location
+	pidFile      string
+	procFS       string
+	logger       log.Logger
+	mu           sync.Mutex
+	restarts     restartTracker
+	roles        roleTracker
+	unknown      stateCounter
+	tunnels      []Tunnel
+
+	trafficStatusCmd     []string
+	addressPoolStatusCmd []string
+	globalStatusCmd      []string
 
 	up                *prometheus.Desc
+	daemon            *prometheus.Desc
 	uptime            *prometheus.Desc
+	restartsTotal     *prometheus.Desc
 	workers           *prometheus.Desc
 	idleWorkers       *prometheus.Desc
 	activeWorkers     *prometheus.Desc
 	queues            *prometheus.Desc
+	scheduledJobs     *prometheus.Desc
+	allocatedBytes    *prometheus.Desc
+	allocations       *prometheus.Desc
+	mallinfoBytes     *prometheus.Desc
+	plugin            *prometheus.Desc
+	listening         *prometheus.Desc
 	ikeSAs            *prometheus.Desc
 	halfOpenIKESAs    *prometheus.Desc
+	states            *prometheus.Desc
+	ikeStates         *prometheus.Desc
+	ddosThreshold     *prometheus.Desc
+	ddosMaxHalfOpen   *prometheus.Desc
+	ddosMode          *prometheus.Desc
+	ddosCookies       *prometheus.Desc
 	poolIPs           *prometheus.Desc
 	onlinePoolIPs     *prometheus.Desc
 	offlinePoolIPs    *prometheus.Desc
+	connectionInfo    *prometheus.Desc
+	connectionUp      *prometheus.Desc
+	connectionDPD     *prometheus.Desc
+	connectionChild   *prometheus.Desc
+	policy            *prometheus.Desc
+	expectedTunnelUp  *prometheus.Desc
+	connIKESAs        *prometheus.Desc
+	connChildSAs      *prometheus.Desc
+	connBytesIn       *prometheus.Desc
+	connPacketsIn     *prometheus.Desc
+	connBytesOut      *prometheus.Desc
+	connPacketsOut    *prometheus.Desc
+	oldestIKESA       *prometheus.Desc
+	newestIKESA       *prometheus.Desc
 	ikeSAState        *prometheus.Desc
 	establishedIKESA  *prometheus.Desc
+	ikeSAStateSet     *prometheus.Desc
+	ikeSAStatus       *prometheus.Desc
+	ikeSAEstablished  *prometheus.Desc
+	ikeSATasks        *prometheus.Desc
+	ikeSAEvent        *prometheus.Desc
+	ikeSANewest       *prometheus.Desc
+	ikeSAIdle         *prometheus.Desc
 	childSAState      *prometheus.Desc
+	childSAStateSet   *prometheus.Desc
+	childSAStatus     *prometheus.Desc
+	childSAUp         *prometheus.Desc
 	childSABytesIn    *prometheus.Desc
 	childSAPacketsIn  *prometheus.Desc
 	childSABytesOut   *prometheus.Desc
 	childSAPacketsOut *prometheus.Desc
 	childSAInstalled  *prometheus.Desc
+	childSAEvent      *prometheus.Desc
+	childSANewest     *prometheus.Desc
+	childSAIdle       *prometheus.Desc
+	unknownStates     *prometheus.Desc
 }
 
 // Describe describes all the metrics exported by the IPsec exporter. It
 // implements prometheus.Collector.
 func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
 	ch <- e.up
+	ch <- e.daemon
 	ch <- e.uptime
+	ch <- e.restartsTotal
 	ch <- e.workers
 	ch <- e.idleWorkers
 	ch <- e.activeWorkers
 	ch <- e.queues
+	ch <- e.scheduledJobs
+	ch <- e.allocatedBytes
+	ch <- e.allocations
+	ch <- e.mallinfoBytes
+	ch <- e.plugin
+	ch <- e.listening
 	ch <- e.ikeSAs
 	ch <- e.halfOpenIKESAs
+	ch <- e.states
+	ch <- e.ikeStates
+	ch <- e.ddosThreshold
+	ch <- e.ddosMaxHalfOpen
+	ch <- e.ddosMode
+	ch <- e.ddosCookies
 	ch <- e.poolIPs
 	ch <- e.onlinePoolIPs
 	ch <- e.offlinePoolIPs
+	ch <- e.connectionInfo
+	ch <- e.connectionUp
+	ch <- e.connectionDPD
+	ch <- e.connectionChild
+	ch <- e.policy
+	ch <- e.expectedTunnelUp
+	ch <- e.connIKESAs
+	ch <- e.connChildSAs
+	ch <- e.connBytesIn
+	ch <- e.connPacketsIn
+	ch <- e.connBytesOut
+	ch <- e.connPacketsOut
+	ch <- e.oldestIKESA
+	ch <- e.newestIKESA
 	ch <- e.ikeSAState
 	ch <- e.establishedIKESA
+	ch <- e.ikeSAStateSet
+	ch <- e.ikeSAStatus
+	ch <- e.ikeSAEstablished
+	ch <- e.ikeSATasks
+	ch <- e.ikeSAEvent
+	ch <- e.ikeSANewest
+	ch <- e.ikeSAIdle
 	ch <- e.childSAState
+	ch <- e.childSAStateSet
+	ch <- e.childSAStatus
+	ch <- e.childSAUp
 	ch <- e.childSABytesIn
 	ch <- e.childSAPacketsIn
 	ch <- e.childSABytesOut
 	ch <- e.childSAPacketsOut
 	ch <- e.childSAInstalled
+	ch <- e.childSAEvent
+	ch <- e.childSANewest
+	ch <- e.childSAIdle
+	ch <- e.unknownStates
 }
 
 // Collect fetches the statistics from strongswan/libreswan, and
@@ -132,6 +272,9 @@ func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
 	m, ok := e.scrape(e)
 	if !ok {
 		ch <- prometheus.MustNewConstMetric(e.up, prometheus.GaugeValue, 0)
+		for _, t := range e.tunnels {
+			ch <- prometheus.MustNewConstMetric(e.expectedTunnelUp, prometheus.GaugeValue, 0, t.Name)
+		}
 		return
 	}
 
@@ -153,21 +296,49 @@ func (e *Exporter) scrapeIpsec() (m metrics, ok bool) {
 		return e.scrapeStrongswan(output)
 	case reLSMarker.Match(output):
 		level.Debug(e.logger).Log("msg", "Output type is detected as libreswan", "cmd", cmd)
-		return e.scrapeLibreswan(output)
+		if m, ok = e.scrapeLibreswan(output); ok {
+			if m.Stats.Uptime.Started, err = e.plutoStartTime(); err != nil {
+				level.Warn(e.logger).Log("msg", "Failed to get pluto start time", "err", err)
+			}
+			e.scrapeLibreswanOptional(&m)
+		}
+		return
+	case reSWMarker.Match(output):
+		level.Debug(e.logger).Log("msg", "Output type is detected as swanctl", "cmd", cmd)
+		return e.scrapeSwanctl(output)
+	case len(bytes.TrimSpace(output)) == 0 && reSWCommand.MatchString(strings.Join(e.ipsecCmd, " ")):
+		level.Debug(e.logger).Log("msg", "Output type is detected as empty swanctl", "cmd", cmd)
+		return e.scrapeSwanctl(output)
 	}
 	level.Error(e.logger).Log("msg", "Failed to recognize output type", "cmd", cmd, "output", output)
 	return
 }
 
+// execOptional runs a command supplementing the main one. Its failure
+// is logged but doesn't fail the scrape.
+func (e *Exporter) execOptional(args []string) (output []byte, ok bool) {
+	ctx := context.Background()
+	if e.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		level.Warn(e.logger).Log("msg", "Failed to execute command", "cmd", cmd, "output", output, "err", err)
+		return nil, false
+	}
+	return output, true
+}
+
 func (e *Exporter) collect(m metrics, ch chan<- prometheus.Metric) {
-	if m.Stats.Uptime.Since != "" {
-		uptime, err := time.ParseInLocation("Jan _2 15:04:05 2006", m.Stats.Uptime.Since, tz)
-		if err != nil {
-			ch <- prometheus.MustNewConstMetric(e.up, prometheus.GaugeValue, 0)
-			level.Error(e.logger).Log("msg", "Failed to parse uptime", "uptime", m.Stats.Uptime.Since, "err", err)
-			return
-		}
-		ch <- prometheus.MustNewConstMetric(e.uptime, prometheus.GaugeValue, now().Round(time.Second).Sub(uptime).Seconds())
+	if m.Daemon.Implementation != "" {
+		ch <- prometheus.MustNewConstMetric(e.daemon, prometheus.GaugeValue, 1, m.Daemon.Implementation, m.Daemon.Version, m.Daemon.SysName, m.Daemon.Release, m.Daemon.Machine)
+	}
+	if started, precision, ok := e.startTime(m.Stats.Uptime); ok {
+		ch <- prometheus.MustNewConstMetric(e.uptime, prometheus.GaugeValue, now().Round(time.Second).Sub(started).Seconds())
+		ch <- prometheus.MustNewConstMetric(e.restartsTotal, prometheus.CounterValue, float64(e.restarts.observe(started, precision)))
 	}
 	if m.Stats.Workers != nil {
 		ch <- prometheus.MustNewConstMetric(e.workers, prometheus.GaugeValue, float64(m.Stats.Workers.Total))
@@ -180,18 +351,117 @@ func (e *Exporter) collect(m metrics, ch chan<- prometheus.Metric) {
 		ch <- prometheus.MustNewConstMetric(e.queues, prometheus.GaugeValue, float64(m.Stats.Queues.Medium), "medium")
 		ch <- prometheus.MustNewConstMetric(e.queues, prometheus.GaugeValue, float64(m.Stats.Queues.Low), "low")
 	}
+	if m.Stats.Scheduled != nil {
+		ch <- prometheus.MustNewConstMetric(e.scheduledJobs, prometheus.GaugeValue, float64(*m.Stats.Scheduled))
+	}
+	if m.Stats.Mem != nil {
+		ch <- prometheus.MustNewConstMetric(e.allocatedBytes, prometheus.GaugeValue, float64(m.Stats.Mem.Total))
+		ch <- prometheus.MustNewConstMetric(e.allocations, prometheus.GaugeValue, float64(m.Stats.Mem.Allocs))
+	}
+	if m.Stats.Mallinfo != nil {
+		ch <- prometheus.MustNewConstMetric(e.mallinfoBytes, prometheus.GaugeValue, float64(m.Stats.Mallinfo.Sbrk), "sbrk")
+		ch <- prometheus.MustNewConstMetric(e.mallinfoBytes, prometheus.GaugeValue, float64(m.Stats.Mallinfo.Mmap), "mmap")
+		ch <- prometheus.MustNewConstMetric(e.mallinfoBytes, prometheus.GaugeValue, float64(m.Stats.Mallinfo.Used), "used")
+		ch <- prometheus.MustNewConstMetric(e.mallinfoBytes, prometheus.GaugeValue, float64(m.Stats.Mallinfo.Free), "free")
+	}
+	for _, plugin := range m.Stats.Plugins {
+		ch <- prometheus.MustNewConstMetric(e.plugin, prometheus.GaugeValue, 1, plugin)
+	}
+	listening := make(map[listeningAddress]bool)
+	for _, addr := range m.Listening {
+		// libreswan lists UDP and TCP listeners separately
+		if listening[addr] {
+			continue
+		}
+		listening[addr] = true
+		ch <- prometheus.MustNewConstMetric(e.listening, prometheus.GaugeValue, 1, addr.Address, addr.Port, addr.Interface)
+	}
 	ch <- prometheus.MustNewConstMetric(e.ikeSAs, prometheus.GaugeValue, float64(m.Stats.IKESAs.Total))
 	ch <- prometheus.MustNewConstMetric(e.halfOpenIKESAs, prometheus.GaugeValue, float64(m.Stats.IKESAs.HalfOpen))
+	for typ, n := range m.Stats.States {
+		ch <- prometheus.MustNewConstMetric(e.states, prometheus.GaugeValue, float64(n), typ)
+	}
+	for category, n := range m.Stats.IKEStates {
+		ch <- prometheus.MustNewConstMetric(e.ikeStates, prometheus.GaugeValue, float64(n), category)
+	}
+	if m.Stats.DDoS.CookiesThreshold != nil {
+		ch <- prometheus.MustNewConstMetric(e.ddosThreshold, prometheus.GaugeValue, float64(*m.Stats.DDoS.CookiesThreshold))
+	}
+	if m.Stats.DDoS.MaxHalfOpen != nil {
+		ch <- prometheus.MustNewConstMetric(e.ddosMaxHalfOpen, prometheus.GaugeValue, float64(*m.Stats.DDoS.MaxHalfOpen))
+	}
+	if m.Stats.DDoS.Mode != "" {
+		ch <- prometheus.MustNewConstMetric(e.ddosMode, prometheus.GaugeValue, 1, m.Stats.DDoS.Mode)
+	}
+	if m.Stats.DDoS.CookiesRequired != nil {
+		ch <- prometheus.MustNewConstMetric(e.ddosCookies, prometheus.GaugeValue, boolToFloat(*m.Stats.DDoS.CookiesRequired))
+	}
 	for _, pool := range m.Pools {
 		ch <- prometheus.MustNewConstMetric(e.poolIPs, prometheus.GaugeValue, float64(pool.Size), pool.Name, pool.Address)
 		ch <- prometheus.MustNewConstMetric(e.onlinePoolIPs, prometheus.GaugeValue, float64(pool.Online), pool.Name, pool.Address)
 		ch <- prometheus.MustNewConstMetric(e.offlinePoolIPs, prometheus.GaugeValue, float64(pool.Offline), pool.Name, pool.Address)
 	}
+	up := make(map[string]bool)
+	for _, ikeSA := range m.IKESAs {
+		if len(ikeSA.ChildSAs) > 0 {
+			up[ikeSA.ConnectionName()] = true
+		}
+	}
+	for _, conn := range m.Connections {
+		version := ""
+		if conn.Version == ikeVersionAny {
+			version = "1/2"
+		} else if conn.Version != 0 {
+			version = strconv.FormatUint(uint64(conn.Version), 10)
+		}
+		ch <- prometheus.MustNewConstMetric(e.connectionInfo, prometheus.GaugeValue, 1,
+			conn.Name,
+			version,
+			conn.LocalHost,
+			conn.LocalID,
+			conn.RemoteHost,
+			conn.RemoteID,
+			conn.LocalAuth,
+			conn.RemoteAuth,
+			conn.Routing,
+			conn.Policy,
+		)
+		ch <- prometheus.MustNewConstMetric(e.connectionUp, prometheus.GaugeValue, boolToFloat(up[conn.Name]), conn.Name)
+		if conn.DPDDelay != nil {
+			ch <- prometheus.MustNewConstMetric(e.connectionDPD, prometheus.GaugeValue, float64(*conn.DPDDelay), conn.Name)
+		}
+		for _, child := range conn.Children {
+			ch <- prometheus.MustNewConstMetric(e.connectionChild, prometheus.GaugeValue, 1,
+				conn.Name,
+				child.Name,
+				child.Mode,
+				strings.Join(child.LocalTS, ", "),
+				strings.Join(child.RemoteTS, ", "),
+				child.DPDAction,
+			)
+		}
+	}
+	for _, policy := range m.Policies {
+		ch <- prometheus.MustNewConstMetric(e.policy, prometheus.GaugeValue, 1,
+			policy.Name,
+			policy.Connection,
+			policy.Type(),
+			policy.Mode,
+			strings.Join(policy.LocalTS, ", "),
+			strings.Join(policy.RemoteTS, ", "),
+		)
+	}
+	for _, t := range e.tunnels {
+		ch <- prometheus.MustNewConstMetric(e.expectedTunnelUp, prometheus.GaugeValue, boolToFloat(t.up(m.IKESAs)), t.Name)
+	}
+	e.collectConnectionStats(m.IKESAs, ch)
+	unknown := make(map[saRef]string)
 	for _, ikeSA := range m.IKESAs {
 		labelValues := []string{
 			ikeSA.Name,
 			strconv.FormatUint(uint64(ikeSA.UID), 10),
 			strconv.FormatUint(uint64(ikeSA.Version), 10),
+			ikeSA.Role(),
 			ikeSA.LocalHost,
 			ikeSA.LocalID,
 			ikeSA.RemoteHost,
@@ -206,9 +476,35 @@ func (e *Exporter) collect(m metrics, ch chan<- prometheus.Metric) {
 		if !math.IsNaN(state) {
 			ch <- prometheus.MustNewConstMetric(e.ikeSAState, prometheus.GaugeValue, state, labelValues...)
 		}
+		if !e.collectStateSet(ch, e.ikeSAStateSet, ikeSAKnownStates[m.Daemon.Implementation], ikeSA.State, labelValues) {
+			unknown[saRef{typ: "ike", uid: ikeSA.UID}] = ikeSA.State
+		}
+		if status, ok := ikeSAStatuses[ikeSA.State]; ok {
+			ch <- prometheus.MustNewConstMetric(e.ikeSAStatus, prometheus.GaugeValue, status, labelValues...)
+			ch <- prometheus.MustNewConstMetric(e.ikeSAEstablished, prometheus.GaugeValue, boolToFloat(isUp(status)), labelValues...)
+		}
 		if ikeSA.State == "ESTABLISHED" && ikeSA.Established != nil {
 			ch <- prometheus.MustNewConstMetric(e.establishedIKESA, prometheus.GaugeValue, float64(*ikeSA.Established), labelValues...)
 		}
+		// Missing task lists aren't reported rather than empty
+		if ikeSA.TasksQueued != nil {
+			ch <- prometheus.MustNewConstMetric(e.ikeSATasks, prometheus.GaugeValue, float64(len(ikeSA.TasksQueued)), append(labelValues, "queued")...)
+		}
+		if ikeSA.TasksActive != nil {
+			ch <- prometheus.MustNewConstMetric(e.ikeSATasks, prometheus.GaugeValue, float64(len(ikeSA.TasksActive)), append(labelValues, "active")...)
+		}
+		if ikeSA.TasksPassive != nil {
+			ch <- prometheus.MustNewConstMetric(e.ikeSATasks, prometheus.GaugeValue, float64(len(ikeSA.TasksPassive)), append(labelValues, "passive")...)
+		}
+		for event, sec := range ikeSA.Events {
+			ch <- prometheus.MustNewConstMetric(e.ikeSAEvent, prometheus.GaugeValue, float64(sec), append(labelValues, event)...)
+		}
+		if ikeSA.Newest != nil {
+			ch <- prometheus.MustNewConstMetric(e.ikeSANewest, prometheus.GaugeValue, boolToFloat(*ikeSA.Newest), labelValues...)
+		}
+		if ikeSA.Idle != nil {
+			ch <- prometheus.MustNewConstMetric(e.ikeSAIdle, prometheus.GaugeValue, boolToFloat(*ikeSA.Idle), labelValues...)
+		}
 		for _, childSA := range ikeSA.ChildSAs {
 			reqID := ""
 			if childSA.ReqID != nil {
@@ -230,6 +526,13 @@ func (e *Exporter) collect(m metrics, ch chan<- prometheus.Metric) {
 			if !math.IsNaN(state) {
 				ch <- prometheus.MustNewConstMetric(e.childSAState, prometheus.GaugeValue, state, childLabelValues...)
 			}
+			if !e.collectStateSet(ch, e.childSAStateSet, childSAKnownStates[m.Daemon.Implementation], childSA.State, childLabelValues) {
+				unknown[saRef{typ: "child", uid: childSA.UID}] = childSA.State
+			}
+			if status, ok := childSAStatuses[childSA.State]; ok {
+				ch <- prometheus.MustNewConstMetric(e.childSAStatus, prometheus.GaugeValue, status, childLabelValues...)
+				ch <- prometheus.MustNewConstMetric(e.childSAUp, prometheus.GaugeValue, boolToFloat(isUp(status)), childLabelValues...)
+			}
 			ch <- prometheus.MustNewConstMetric(e.childSABytesIn, prometheus.GaugeValue, float64(childSA.InBytes), childLabelValues...)
 			if childSA.InPackets != nil {
 				ch <- prometheus.MustNewConstMetric(e.childSAPacketsIn, prometheus.GaugeValue, float64(*childSA.InPackets), childLabelValues...)
@@ -241,18 +544,251 @@ func (e *Exporter) collect(m metrics, ch chan<- prometheus.Metric) {
 			if childSA.Installed != nil {
 				ch <- prometheus.MustNewConstMetric(e.childSAInstalled, prometheus.GaugeValue, float64(*childSA.Installed), childLabelValues...)
 			}
+			for event, sec := range childSA.Events {
+				ch <- prometheus.MustNewConstMetric(e.childSAEvent, prometheus.GaugeValue, float64(sec), append(childLabelValues, event)...)
+			}
+			if childSA.Newest != nil {
+				ch <- prometheus.MustNewConstMetric(e.childSANewest, prometheus.GaugeValue, boolToFloat(*childSA.Newest), childLabelValues...)
+			}
+			if childSA.Idle != nil {
+				ch <- prometheus.MustNewConstMetric(e.childSAIdle, prometheus.GaugeValue, boolToFloat(*childSA.Idle), childLabelValues...)
+			}
+		}
+	}
+	for _, ref := range e.unknown.observe(unknown) {
+		level.Warn(e.logger).Log("msg", "Unknown SA state", "type", ref.typ, "uid", ref.uid, "state", unknown[ref])
+	}
+	for typ, states := range e.unknown.counts() {
+		for state, n := range states {
+			ch <- prometheus.MustNewConstMetric(e.unknownStates, prometheus.CounterValue, float64(n), typ, state)
 		}
 	}
 	ch <- prometheus.MustNewConstMetric(e.up, prometheus.GaugeValue, 1)
 }
 
+// collectStateSet emits a series for each known state and reports whether
+// the state is one of them.
+func (e *Exporter) collectStateSet(ch chan<- prometheus.Metric, desc *prometheus.Desc, known map[string]float64, state string, labelValues []string) bool {
+	if state == "" {
+		return true
+	}
+	for s := range known {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, boolToFloat(s == state), append(labelValues, s)...)
+	}
+	_, ok := known[state]
+	return ok
+}
+
+// connectionStats is an aggregate of the SAs of a connection.
+type connectionStats struct {
+	ikeSAs     map[string]uint64
+	childSAs   map[string]uint64
+	inBytes    uint64
+	outBytes   uint64
+	inPackets  *uint64
+	outPackets *uint64
+	oldest     *int64
+	newest     *int64
+}
+
+// collectConnectionStats emits aggregates of the SAs by connection name.
+func (e *Exporter) collectConnectionStats(ikeSAs []*ikeSA, ch chan<- prometheus.Metric) {
+	conns := make(map[string]*connectionStats)
+	for _, ikeSA := range ikeSAs {
+		name := ikeSA.ConnectionName()
+		stats := conns[name]
+		if stats == nil {
+			stats = &connectionStats{
+				ikeSAs:   make(map[string]uint64),
+				childSAs: make(map[string]uint64),
+			}
+			conns[name] = stats
+		}
+		stats.ikeSAs[ikeSA.State]++
+		if ikeSA.Established != nil {
+			if stats.oldest == nil || *ikeSA.Established > *stats.oldest {
+				stats.oldest = ikeSA.Established
+			}
+			if stats.newest == nil || *ikeSA.Established < *stats.newest {
+				stats.newest = ikeSA.Established
+			}
+		}
+		for _, childSA := range ikeSA.ChildSAs {
+			stats.childSAs[childSA.State]++
+			stats.inBytes += childSA.InBytes
+			stats.outBytes += childSA.OutBytes
+			stats.inPackets = addUint64(stats.inPackets, childSA.InPackets)
+			stats.outPackets = addUint64(stats.outPackets, childSA.OutPackets)
+		}
+	}
+	for name, stats := range conns {
+		for state, n := range stats.ikeSAs {
+			ch <- prometheus.MustNewConstMetric(e.connIKESAs, prometheus.GaugeValue, float64(n), name, state)
+		}
+		for state, n := range stats.childSAs {
+			ch <- prometheus.MustNewConstMetric(e.connChildSAs, prometheus.GaugeValue, float64(n), name, state)
+		}
+		ch <- prometheus.MustNewConstMetric(e.connBytesIn, prometheus.GaugeValue, float64(stats.inBytes), name)
+		ch <- prometheus.MustNewConstMetric(e.connBytesOut, prometheus.GaugeValue, float64(stats.outBytes), name)
+		if stats.inPackets != nil {
+			ch <- prometheus.MustNewConstMetric(e.connPacketsIn, prometheus.GaugeValue, float64(*stats.inPackets), name)
+		}
+		if stats.outPackets != nil {
+			ch <- prometheus.MustNewConstMetric(e.connPacketsOut, prometheus.GaugeValue, float64(*stats.outPackets), name)
+		}
+		if stats.oldest != nil {
+			ch <- prometheus.MustNewConstMetric(e.oldestIKESA, prometheus.GaugeValue, float64(*stats.oldest), name)
+			ch <- prometheus.MustNewConstMetric(e.newestIKESA, prometheus.GaugeValue, float64(*stats.newest), name)
+		}
+	}
+}
+
+// addUint64 returns the sum of the optional values or nil if both are nil.
+func addUint64(a, b *uint64) *uint64 {
+	if b == nil {
+		return a
+	}
+	sum := *b
+	if a != nil {
+		sum += *a
+	}
+	return &sum
+}
+
+// isUp reports whether an SA with the status is usable for traffic, SAs
+// being rekeyed still are.
+func isUp(status float64) bool {
+	return status == statusEstablished || status == statusRekeying
+}
+
+// stateCounter counts SAs seen in unknown states by SA type.
+type stateCounter struct {
+	mu     sync.Mutex
+	seen   map[saRef]string
+	states map[string]map[string]uint64
+}
+
+// saRef identifies an SA of the type.
+type saRef struct {
+	typ string
+	uid uint32
+}
+
+// observe counts the SAs in the unknown states unless they were in the same
+// state in the previous scrape and returns the counted ones. Only the SAs
+// of the scrape are remembered as uids aren't reused.
+func (c *stateCounter) observe(unknown map[saRef]string) []saRef {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.states == nil {
+		c.states = make(map[string]map[string]uint64)
+	}
+	var counted []saRef
+	for ref, state := range unknown {
+		if c.seen[ref] == state {
+			continue
+		}
+		if c.states[ref.typ] == nil {
+			c.states[ref.typ] = make(map[string]uint64)
+		}
+		c.states[ref.typ][state]++
+		counted = append(counted, ref)
+	}
+	c.seen = unknown
+	return counted
+}
+
+func (c *stateCounter) counts() map[string]map[string]uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counts := make(map[string]map[string]uint64, len(c.states))
+	for typ, states := range c.states {
+		counts[typ] = make(map[string]uint64, len(states))
+		for state, n := range states {
+			counts[typ][state] = n
+		}
+	}
+	return counts
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Option configures an exporter.
+type Option func(e *Exporter)
+
+// WithLocation sets the timezone the daemon reports its start time in.
+// The local timezone is used by default.
+func WithLocation(loc *time.Location) Option {
+	return func(e *Exporter) { e.location = loc }
+}
+
+// WithWhackSocket sets the pluto control socket path used by the whack
+// collector. "/run/pluto/pluto.ctl" is used by default.
+func WithWhackSocket(path string) Option {
+	return func(e *Exporter) { e.whackSocket = path }
+}
+
+// WithStrokeSocket sets the charon stroke socket path used by the stroke
+// collector. "/var/run/charon.ctl" is used by default.
+func WithStrokeSocket(path string) Option {
+	return func(e *Exporter) { e.strokeSocket = path }
+}
+
+// WithExpectedTunnels sets the tunnels to export the up status of.
+func WithExpectedTunnels(tunnels []Tunnel) Option {
+	return func(e *Exporter) { e.tunnels = tunnels }
+}
+
+// WithTrafficStatusCommand sets the command to merge libreswan traffic
+// status from, usually "ipsec whack --trafficstatus". It provides exact
+// byte counts, install times, XAuth usernames, IKE identities and leases.
+func WithTrafficStatusCommand(cmd []string) Option {
+	return func(e *Exporter) { e.trafficStatusCmd = cmd }
+}
+
+// WithAddressPoolStatusCommand sets the command to get libreswan address
+// pools from, usually "ipsec whack --addresspoolstatus".
+func WithAddressPoolStatusCommand(cmd []string) Option {
+	return func(e *Exporter) { e.addressPoolStatusCmd = cmd }
+}
+
+// WithGlobalStatusCommand sets the command to get libreswan global state
+// counts from, usually "ipsec whack --globalstatus".
+func WithGlobalStatusCommand(cmd []string) Option {
+	return func(e *Exporter) { e.globalStatusCmd = cmd }
+}
+
+// WithPlutoPIDFile sets the pluto pid file path used to get the libreswan
+// daemon start time. "/run/pluto/pluto.pid" is used by default.
+func WithPlutoPIDFile(path string) Option {
+	return func(e *Exporter) { e.pidFile = path }
+}
+
+// WithProcFS sets the procfs mount point used to get the libreswan daemon
+// start time. "/proc" is used by default. The start time in the process
+// stat is converted from clock ticks assuming USER_HZ is 100, which
+// is the case for all the Linux architectures.
+func WithProcFS(path string) Option {
+	return func(e *Exporter) { e.procFS = path }
+}
+
 // New returns an initialized exporter.
-func New(collectorType int, address *url.URL, timeout time.Duration, ipsecCmd []string, logger log.Logger) (*Exporter, error) {
+func New(collectorType int, address *url.URL, timeout time.Duration, ipsecCmd []string, logger log.Logger, opts ...Option) (*Exporter, error) {
 	e := &Exporter{
-		address:  address,
-		timeout:  timeout,
-		ipsecCmd: ipsecCmd,
-		logger:   logger,
+		address:      address,
+		timeout:      timeout,
+		ipsecCmd:     ipsecCmd,
+		whackSocket:  "/run/pluto/pluto.ctl",
+		strokeSocket: "/var/run/charon.ctl",
+		location:     tz,
+		pidFile:      "/run/pluto/pluto.pid",
+		procFS:       "/proc",
+		logger:       logger,
 
 		up: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "", "up"),
@@ -260,12 +796,24 @@ func New(collectorType int, address *url.URL, timeout time.Duration, ipsecCmd []
 			nil,
 			nil,
 		),
+		daemon: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "daemon_info"),
+			"IKE daemon information.",
+			[]string{"implementation", "version", "sysname", "release", "machine"},
+			nil,
+		),
 		uptime: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "", "uptime_seconds"),
 			"Number of seconds since the daemon started.",
 			nil,
 			nil,
 		),
+		restartsTotal: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "daemon_restarts_total"),
+			"Number of daemon restarts observed by the exporter.",
+			nil,
+			nil,
+		),
 		workers: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "", "workers_total"),
 			"Number of worker threads.",
@@ -290,6 +838,42 @@ func New(collectorType int, address *url.URL, timeout time.Duration, ipsecCmd []
 			[]string{"priority"},
 			nil,
 		),
+		scheduledJobs: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "scheduled_jobs"),
+			"Number of jobs scheduled for timed execution.",
+			nil,
+			nil,
+		),
+		allocatedBytes: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "allocated_bytes"),
+			"Number of bytes allocated, as tracked by the leak detective.",
+			nil,
+			nil,
+		),
+		allocations: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "allocations"),
+			"Number of allocations, as tracked by the leak detective.",
+			nil,
+			nil,
+		),
+		mallinfoBytes: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "mallinfo_bytes"),
+			"Number of bytes reported by mallinfo.",
+			[]string{"type"},
+			nil,
+		),
+		plugin: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "plugin_info"),
+			"Loaded plugin.",
+			[]string{"name"},
+			nil,
+		),
+		listening: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "listening_address_info"),
+			"Address the daemon listens on.",
+			[]string{"address", "port", "interface"},
+			nil,
+		),
 		ikeSAs: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "", "ike_sas"),
 			"Number of currently registered IKE SAs.",
@@ -302,6 +886,42 @@ func New(collectorType int, address *url.URL, timeout time.Duration, ipsecCmd []
 			nil,
 			nil,
 		),
+		states: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "states"),
+			"Number of current states by type.",
+			[]string{"type"},
+			nil,
+		),
+		ikeStates: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "ike_states"),
+			"Number of current IKE states by category.",
+			[]string{"category"},
+			nil,
+		),
+		ddosThreshold: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "ddos_cookies_threshold"),
+			"Number of half-open IKE SAs above which DDoS cookies are required.",
+			nil,
+			nil,
+		),
+		ddosMaxHalfOpen: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "ddos_max_half_open_ike_sas"),
+			"Number of half-open IKE SAs above which new IKE connections are rejected.",
+			nil,
+			nil,
+		),
+		ddosMode: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "ddos_mode"),
+			"DDoS protection mode.",
+			[]string{"mode"},
+			nil,
+		),
+		ddosCookies: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "ddos_cookies_required"),
+			"Whether DDoS cookies are currently required.",
+			nil,
+			nil,
+		),
 		poolIPs: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "", "pool_ips_total"),
 			"Number of addresses in the pool.",
@@ -320,6 +940,90 @@ func New(collectorType int, address *url.URL, timeout time.Duration, ipsecCmd []
 			[]string{"name", "address"},
 			nil,
 		),
+		connectionInfo: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "connection_info"),
+			"Configured connection.",
+			connectionLbls,
+			nil,
+		),
+		connectionUp: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "connection_up"),
+			"Whether the connection has an IKE SA with at least one child SA.",
+			[]string{"name"},
+			nil,
+		),
+		connectionDPD: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "connection_dpd_delay_seconds"),
+			"Number of seconds between the connection DPD checks.",
+			[]string{"name"},
+			nil,
+		),
+		connectionChild: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "connection_child_info"),
+			"Configured connection child.",
+			[]string{"name", "child", "mode", "local_ts", "remote_ts", "dpd_action"},
+			nil,
+		),
+		policy: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "policy_info"),
+			"Installed trap or shunt policy.",
+			[]string{"name", "connection", "type", "mode", "local_ts", "remote_ts"},
+			nil,
+		),
+		expectedTunnelUp: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "expected_tunnel_up"),
+			"Whether the expected tunnel has enough installed child SAs.",
+			[]string{"tunnel"},
+			nil,
+		),
+		connIKESAs: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "connection_ike_sas"),
+			"Number of IKE SAs of the connection by state.",
+			[]string{"name", "state"},
+			nil,
+		),
+		connChildSAs: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "connection_child_sas"),
+			"Number of child SAs of the connection by state.",
+			[]string{"name", "state"},
+			nil,
+		),
+		connBytesIn: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "connection_bytes_in"),
+			"Number of input bytes processed by the child SAs of the connection.",
+			[]string{"name"},
+			nil,
+		),
+		connPacketsIn: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "connection_packets_in"),
+			"Number of input packets processed by the child SAs of the connection.",
+			[]string{"name"},
+			nil,
+		),
+		connBytesOut: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "connection_bytes_out"),
+			"Number of output bytes processed by the child SAs of the connection.",
+			[]string{"name"},
+			nil,
+		),
+		connPacketsOut: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "connection_packets_out"),
+			"Number of output packets processed by the child SAs of the connection.",
+			[]string{"name"},
+			nil,
+		),
+		oldestIKESA: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "connection_oldest_ike_sa_seconds"),
+			"Number of seconds since the oldest IKE SA of the connection was established.",
+			[]string{"name"},
+			nil,
+		),
+		newestIKESA: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "connection_newest_ike_sa_seconds"),
+			"Number of seconds since the newest IKE SA of the connection was established.",
+			[]string{"name"},
+			nil,
+		),
 		ikeSAState: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "", "ike_sa_state"),
 			"IKE SA state.",
@@ -332,12 +1036,72 @@ func New(collectorType int, address *url.URL, timeout time.Duration, ipsecCmd []
 			ikeSALbls,
 			nil,
 		),
+		ikeSAStateSet: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "ike_sa_stateset"),
+			"Whether the IKE SA is in the state, one series per known state.",
+			append(ikeSALbls, "state"),
+			nil,
+		),
+		ikeSAStatus: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "ike_sa_status"),
+			"IKE SA status, the same for all the implementations.",
+			ikeSALbls,
+			nil,
+		),
+		ikeSAEstablished: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "ike_sa_established"),
+			"Whether the IKE SA is established.",
+			ikeSALbls,
+			nil,
+		),
+		ikeSATasks: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "ike_sa_tasks"),
+			"Number of IKE SA tasks.",
+			append(ikeSALbls, "queue"),
+			nil,
+		),
+		ikeSAEvent: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "ike_sa_event_seconds"),
+			"Number of seconds until the IKE SA event.",
+			append(ikeSALbls, "event"),
+			nil,
+		),
+		ikeSANewest: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "ike_sa_newest"),
+			"Whether the IKE SA is the newest one of its connection.",
+			ikeSALbls,
+			nil,
+		),
+		ikeSAIdle: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "ike_sa_idle"),
+			"Whether the IKE SA has no pending crypto or DNS work.",
+			ikeSALbls,
+			nil,
+		),
 		childSAState: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "", "child_sa_state"),
 			"Child SA state.",
 			childSALbls,
 			nil,
 		),
+		childSAStateSet: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "child_sa_stateset"),
+			"Whether the child SA is in the state, one series per known state.",
+			append(childSALbls, "state"),
+			nil,
+		),
+		childSAStatus: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "child_sa_status"),
+			"Child SA status, the same for all the implementations.",
+			childSALbls,
+			nil,
+		),
+		childSAUp: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "child_sa_installed"),
+			"Whether the child SA is installed.",
+			childSALbls,
+			nil,
+		),
 		childSABytesIn: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "", "child_sa_bytes_in"),
 			"Number of input bytes processed.",
@@ -368,12 +1132,43 @@ func New(collectorType int, address *url.URL, timeout time.Duration, ipsecCmd []
 			childSALbls,
 			nil,
 		),
+		childSAEvent: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "child_sa_event_seconds"),
+			"Number of seconds until the child SA event.",
+			append(childSALbls, "event"),
+			nil,
+		),
+		childSANewest: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "child_sa_newest"),
+			"Whether the child SA is the newest one of its connection.",
+			childSALbls,
+			nil,
+		),
+		childSAIdle: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "child_sa_idle"),
+			"Whether the child SA has no pending crypto or DNS work.",
+			childSALbls,
+			nil,
+		),
+		unknownStates: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "unknown_sa_states_total"),
+			"Number of SAs seen in an unknown state.",
+			[]string{"type", "state"},
+			nil,
+		),
+	}
+	for _, opt := range opts {
+		opt(e)
 	}
 	switch collectorType {
 	case CollectorVICI:
 		e.scrape = (*Exporter).scrapeVICI
 	case CollectorIpsec:
 		e.scrape = (*Exporter).scrapeIpsec
+	case CollectorWhack:
+		e.scrape = (*Exporter).scrapeWhack
+	case CollectorStroke:
+		e.scrape = (*Exporter).scrapeStroke
 	default:
 		return nil, fmt.Errorf("unknown collector type %d", collectorType)
 	}
@@ -2,6 +2,7 @@ package exporter
 
 import (
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -25,9 +26,17 @@ func TestExporter_Collect(t *testing.T) {
 	exporter.scrape = func(e *Exporter) (m metrics, ok bool) {
 		sec := int64(123)
 		return metrics{
+			Daemon: daemon{
+				Implementation: "strongswan",
+				Version:        "5.9.1",
+				SysName:        "Linux",
+				Release:        "5.4.39-linuxkit",
+				Machine:        "x86_64",
+			},
 			Stats: stats{
 				Uptime: uptime{
-					Since: now().Round(time.Second).Add(-3 * time.Minute).Format("Jan _2 15:04:05 2006"),
+					Running: "3 minutes",
+					Since:   now().Round(time.Second).Add(-3 * time.Minute).Format("Jan _2 15:04:05 2006"),
 				},
 				Workers: &workers{
 					Total: 10,
@@ -50,6 +59,22 @@ func TestExporter_Collect(t *testing.T) {
 					Total:    10,
 					HalfOpen: 5,
 				},
+				Plugins: []string{"charon", "vici"},
+				Mem: &mem{
+					Total:  1024,
+					Allocs: 16,
+				},
+				Mallinfo: &mallinfo{
+					Sbrk: 1622016,
+					Mmap: 0,
+					Used: 512480,
+					Free: 1109536,
+				},
+			},
+			Listening: []listeningAddress{
+				{Address: "192.0.2.1", Port: "500", Interface: "eth0"},
+				{Address: "192.0.2.1", Port: "500", Interface: "eth0"},
+				{Address: "2001:db8::1"},
 			},
 			Pools: []pool{
 				{
@@ -66,12 +91,30 @@ func TestExporter_Collect(t *testing.T) {
 					Offline: 0,
 				},
 			},
+			Policies: []policy{
+				{
+					Name:       "named-trap",
+					Connection: "named",
+					Mode:       "TUNNEL",
+					LocalTS:    []string{"10.1.0.0/16"},
+					RemoteTS:   []string{"10.2.0.0/16", "10.3.0.0/16"},
+				},
+				{
+					Name:     "bypass",
+					Mode:     "PASS",
+					LocalTS:  []string{"192.168.0.0/24"},
+					RemoteTS: []string{"192.168.0.0/24"},
+				},
+			},
 			IKESAs: []*ikeSA{
 				{
 					Name:          "named-1",
 					UID:           1,
 					Version:       1,
 					State:         "ESTABLISHED",
+					Initiator:     newBool(true),
+					InitiatorSPI:  "43cc5f77aa48bbc1",
+					ResponderSPI:  "9748fb98f4d0ba94",
 					LocalHost:     "10.0.2.1",
 					LocalID:       "local",
 					RemoteHost:    "10.0.3.1",
@@ -80,6 +123,7 @@ func TestExporter_Collect(t *testing.T) {
 					Established:   &sec,
 					LocalVIPs:     []string{"192.168.0.1"},
 					RemoteVIPs:    []string{"192.168.0.2"},
+					TasksQueued:   []string{"QUICK_MODE"},
 					ChildSAs: map[string]*childSA{
 						"named-3": {
 							Name:       "named",
@@ -117,10 +161,15 @@ func TestExporter_Collect(t *testing.T) {
 					UID:        2,
 					Version:    2,
 					State:      "ESTABLISHED",
+					Initiator:  newBool(false),
 					LocalHost:  "10.0.2.2",
 					LocalID:    "foo",
 					RemoteHost: "10.0.3.2",
 					RemoteID:   "bar",
+					TasksActive: []string{
+						"CHILD_REKEY",
+						"CHILD_DELETE",
+					},
 					ChildSAs: map[string]*childSA{
 						"named-5": {
 							Name:       "named",
@@ -138,6 +187,14 @@ func TestExporter_Collect(t *testing.T) {
 						},
 					},
 				},
+				{
+					Name:       "named-3",
+					UID:        3,
+					Version:    2,
+					State:      "UNKNOWN",
+					LocalHost:  "10.0.2.3",
+					RemoteHost: "10.0.3.3",
+				},
 			},
 		}, true
 	}
@@ -170,5 +227,61 @@ ipsec_up 0
 	}
 }
 
+func TestSAStatuses(t *testing.T) {
+	// Informational exchanges aren't SAs
+	noStatus := map[string]bool{"STATE_INFO": true, "STATE_INFO_PROTECTED": true}
+	for state := range ikeSAStates {
+		if _, ok := ikeSAStatuses[state]; !ok && !noStatus[state] {
+			t.Errorf("ikeSAStatuses[%q] is missing", state)
+		}
+	}
+	for state := range childSAStates {
+		if _, ok := childSAStatuses[state]; !ok && !noStatus[state] {
+			t.Errorf("childSAStatuses[%q] is missing", state)
+		}
+	}
+}
+
+func TestStateCounter(t *testing.T) {
+	var c stateCounter
+	tests := []struct {
+		unknown map[saRef]string
+		want    []saRef
+	}{
+		{
+			unknown: map[saRef]string{{typ: "ike", uid: 1}: "NEW"},
+			want:    []saRef{{typ: "ike", uid: 1}},
+		},
+		{
+			unknown: map[saRef]string{{typ: "ike", uid: 1}: "NEW", {typ: "child", uid: 1}: "NEW"},
+			want:    []saRef{{typ: "child", uid: 1}},
+		},
+		{
+			unknown: map[saRef]string{{typ: "ike", uid: 1}: "NEWER"},
+			want:    []saRef{{typ: "ike", uid: 1}},
+		},
+		{
+			unknown: map[saRef]string{},
+		},
+		{
+			unknown: map[saRef]string{{typ: "ike", uid: 1}: "NEWER"},
+			want:    []saRef{{typ: "ike", uid: 1}},
+		},
+	}
+	for i, td := range tests {
+		if got := c.observe(td.unknown); !reflect.DeepEqual(got, td.want) {
+			t.Errorf("#%d observe(%v) = %v; want %v", i, td.unknown, got, td.want)
+		}
+	}
+	if len(c.seen) != 1 {
+		t.Errorf("len(seen) = %d; want 1", len(c.seen))
+	}
+	want := map[string]map[string]uint64{"ike": {"NEW": 1, "NEWER": 2}, "child": {"NEW": 1}}
+	if got := c.counts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("counts() = %v; want %v", got, want)
+	}
+}
+
+func newBool(b bool) *bool       { return &b }
 func newUint32(n uint32) *uint32 { return &n }
 func newUint64(n uint64) *uint64 { return &n }
@@ -32,6 +32,8 @@ var redactedLbls = []string{
 	"uid",
 	"ike_sa_uid",
 	"reqid",
+	"role",
+	"ike_sa_role",
 }
 
 type redactedMetric struct {
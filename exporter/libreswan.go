@@ -5,10 +5,11 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 const (
-	lsPrefix     = `^[^ ]+ `
+	lsPrefix     = `^(?:\d+ )?`
 	lsIPAddrPart = `[a-f0-9:.]+`
 	lsIPNetPart  = lsIPAddrPart + `/\d+`
 	lsConnPart   = `"(?P<conname>[^"]+)"(?P<coninst>\[\d+])?`
@@ -89,27 +90,194 @@ var lsStates = map[string]float64{
 	"STATE_V2_CHILD_SA_DELETE":      48,
 }
 
+// lsIKESAStates are the states of ISAKMP and IKE SAs.
+var lsIKESAStates = []string{
+	"STATE_MAIN_R0",
+	"STATE_MAIN_I1",
+	"STATE_MAIN_R1",
+	"STATE_MAIN_I2",
+	"STATE_MAIN_R2",
+	"STATE_MAIN_I3",
+	"STATE_MAIN_R3",
+	"STATE_MAIN_I4",
+	"STATE_AGGR_R0",
+	"STATE_AGGR_I1",
+	"STATE_AGGR_R1",
+	"STATE_AGGR_I2",
+	"STATE_AGGR_R2",
+	"STATE_XAUTH_R0",
+	"STATE_XAUTH_R1",
+	"STATE_MODE_CFG_R0",
+	"STATE_MODE_CFG_R1",
+	"STATE_MODE_CFG_R2",
+	"STATE_MODE_CFG_I1",
+	"STATE_XAUTH_I0",
+	"STATE_XAUTH_I1",
+
+	"STATE_V2_PARENT_I0",
+	"STATE_V2_PARENT_I1",
+	"STATE_V2_PARENT_I2",
+	"STATE_V2_PARENT_R0",
+	"STATE_V2_PARENT_R1",
+	"STATE_V2_REKEY_IKE_I0",
+	"STATE_V2_REKEY_IKE_I1",
+	"STATE_V2_REKEY_IKE_R0",
+	"STATE_V2_ESTABLISHED_IKE_SA",
+	"STATE_V2_IKE_SA_DELETE",
+}
+
+// lsChildSAStates are the states of IPsec SAs. The IKE_AUTH_CHILD states are
+// of larval child SAs negotiated with IKE_AUTH, they're printed with
+// a reference to their IKE SA like other child SAs.
+var lsChildSAStates = []string{
+	"STATE_QUICK_R0",
+	"STATE_QUICK_I1",
+	"STATE_QUICK_R1",
+	"STATE_QUICK_I2",
+	"STATE_QUICK_R2",
+
+	"STATE_V2_IKE_AUTH_CHILD_I0",
+	"STATE_V2_IKE_AUTH_CHILD_R0",
+	"STATE_V2_NEW_CHILD_I0",
+	"STATE_V2_NEW_CHILD_I1",
+	"STATE_V2_NEW_CHILD_R0",
+	"STATE_V2_REKEY_CHILD_I0",
+	"STATE_V2_REKEY_CHILD_I1",
+	"STATE_V2_REKEY_CHILD_R0",
+	"STATE_V2_ESTABLISHED_CHILD_SA",
+	"STATE_V2_CHILD_SA_DELETE",
+}
+
+// lsStatuses maps the states to the SA statuses. IKEv1 XAUTH and ModeCfg
+// states follow the ISAKMP SA authentication but the SA isn't usable
+// until they complete.
+var lsStatuses = map[string]float64{
+	"STATE_MAIN_R0":     statusConnecting,
+	"STATE_MAIN_I1":     statusConnecting,
+	"STATE_MAIN_R1":     statusConnecting,
+	"STATE_MAIN_I2":     statusConnecting,
+	"STATE_MAIN_R2":     statusConnecting,
+	"STATE_MAIN_I3":     statusConnecting,
+	"STATE_MAIN_R3":     statusEstablished,
+	"STATE_MAIN_I4":     statusEstablished,
+	"STATE_AGGR_R0":     statusConnecting,
+	"STATE_AGGR_I1":     statusConnecting,
+	"STATE_AGGR_R1":     statusConnecting,
+	"STATE_AGGR_I2":     statusEstablished,
+	"STATE_AGGR_R2":     statusEstablished,
+	"STATE_QUICK_R0":    statusConnecting,
+	"STATE_QUICK_I1":    statusConnecting,
+	"STATE_QUICK_R1":    statusConnecting,
+	"STATE_QUICK_I2":    statusEstablished,
+	"STATE_QUICK_R2":    statusEstablished,
+	"STATE_XAUTH_R0":    statusConnecting,
+	"STATE_XAUTH_R1":    statusConnecting,
+	"STATE_MODE_CFG_R0": statusConnecting,
+	"STATE_MODE_CFG_R1": statusConnecting,
+	"STATE_MODE_CFG_R2": statusConnecting,
+	"STATE_MODE_CFG_I1": statusConnecting,
+	"STATE_XAUTH_I0":    statusConnecting,
+	"STATE_XAUTH_I1":    statusConnecting,
+
+	"STATE_V2_PARENT_I0":            statusConnecting,
+	"STATE_V2_PARENT_I1":            statusConnecting,
+	"STATE_V2_PARENT_I2":            statusConnecting,
+	"STATE_V2_PARENT_R0":            statusConnecting,
+	"STATE_V2_PARENT_R1":            statusConnecting,
+	"STATE_V2_IKE_AUTH_CHILD_I0":    statusConnecting,
+	"STATE_V2_IKE_AUTH_CHILD_R0":    statusConnecting,
+	"STATE_V2_NEW_CHILD_I0":         statusConnecting,
+	"STATE_V2_NEW_CHILD_I1":         statusConnecting,
+	"STATE_V2_REKEY_IKE_I0":         statusRekeying,
+	"STATE_V2_REKEY_IKE_I1":         statusRekeying,
+	"STATE_V2_REKEY_CHILD_I0":       statusRekeying,
+	"STATE_V2_REKEY_CHILD_I1":       statusRekeying,
+	"STATE_V2_NEW_CHILD_R0":         statusConnecting,
+	"STATE_V2_REKEY_IKE_R0":         statusRekeying,
+	"STATE_V2_REKEY_CHILD_R0":       statusRekeying,
+	"STATE_V2_ESTABLISHED_IKE_SA":   statusEstablished,
+	"STATE_V2_ESTABLISHED_CHILD_SA": statusEstablished,
+	"STATE_V2_IKE_SA_DELETE":        statusDeleting,
+	"STATE_V2_CHILD_SA_DELETE":      statusDeleting,
+}
+
+// lsStateAliases maps IKEv2 state names renamed in libreswan 4.x to the
+// original ones.
+var lsStateAliases = map[string]string{
+	"STATE_V2_IKE_SA_INIT_I0": "STATE_V2_PARENT_I0",
+	"STATE_V2_IKE_SA_INIT_I":  "STATE_V2_PARENT_I1",
+	"STATE_V2_IKE_AUTH_I":     "STATE_V2_PARENT_I2",
+	"STATE_V2_IKE_SA_INIT_R0": "STATE_V2_PARENT_R0",
+	"STATE_V2_IKE_SA_INIT_R":  "STATE_V2_PARENT_R1",
+}
+
 var (
 	lsConnRE = regexp.MustCompile(lsConn)
 	lsAddrRE = regexp.MustCompile(lsAddr)
 )
 
+var (
+	lsRoutingRE = regexp.MustCompile(`^[^;]*; ([^;]+);`)
+	lsAuthRE    = regexp.MustCompile(`^: +our auth:([^,]+), their auth:([^,;]+)`)
+	lsPolicyRE  = regexp.MustCompile(`^: +policy: ([^;]*);`)
+	lsReqIDRE   = regexp.MustCompile(`\breqid[:=] ?(\d+)`)
+)
+
 var (
 	lsStateRE     = regexp.MustCompile(lsState)
-	lsParentIDRE  = regexp.MustCompile(`; isakmp#(\d+)`)
-	lsStateNameRE = regexp.MustCompile(`(STATE_\w+)`)
-	lsSPIRE       = regexp.MustCompile(`([a-z]+)[?:.][a-f0-9]+@` + lsIPAddrPart)
+	lsParentIDRE  = regexp.MustCompile(`; (?:isakmp#|IKE SA #)(\d+)`)
+	lsStateNameRE = regexp.MustCompile(`\b((?:STATE_)?[A-Z][A-Z0-9]*(?:_[A-Z0-9]+)+) \(`)
+	lsStateRoleRE = regexp.MustCompile(`_([IR])\d*$`)
+	lsSPIRE       = regexp.MustCompile(`([a-z]+)[?:.]([a-f0-9]+)@(` + lsIPAddrPart + `)`)
 	lsTrafficRE   = regexp.MustCompile(`(AHin|AHout|ESPin|ESPout|IPCOMPin|IPCOMPout)=(\d+)(B|KB|MB)`)
 	lsUsernameRE  = regexp.MustCompile(` username=(.+)$`)
+	lsEventRE     = regexp.MustCompile(`; (?:EVENT_(?:SA_|v1_|v2_)?)?([A-Z_]+) in (-?\d+)s`)
+	lsNewestRE    = regexp.MustCompile(`; newest(?: ISAKMP| IPSEC)?;`)
+	lsIdleRE      = regexp.MustCompile(`; (idle|crypto_calculating|crypto/DNS-lookup);`)
+)
+
+var lsTrafficStatusRE = regexp.MustCompile(lsPrefix +
+	`#(?P<serialno>\d+): ` +
+	lsConnPart +
+	`[^,]*, ` +
+	`(?:username=(?P<username>[^,]+), )?` +
+	`type=(?P<type>\w+), ` +
+	`add_time=(?P<addtime>\d+), ` +
+	`inBytes=(?P<inbytes>\d+), ` +
+	`outBytes=(?P<outbytes>\d+)` +
+	`(?:, maxBytes=[^,]+)?` +
+	`(?:, id='(?P<id>[^']*)')?` +
+	`(?:, lease=(?P<lease>[^,/]+)(?:/\d+)?)?`)
+
+var lsAddressPoolRE = regexp.MustCompile(lsPrefix +
+	`address pool (?P<range>(?P<start>` + lsIPAddrPart + `?)(?:-` + lsIPAddrPart + `?|/\d+)?):? ` +
+	`(?:size|total) (?P<size>\d+), ` +
+	`leases (?P<leases>\d+), ` +
+	`in-use (?P<inuse>\d+)`)
+
+var (
+	lsGlobalStatesRE        = regexp.MustCompile(lsPrefix + `current\.states\.(ipsec|ike|shunts)=(\d+)$`)
+	lsGlobalIKEStatesRE     = regexp.MustCompile(lsPrefix + `current\.states\.iketype\.(\w+)=(\d+)$`)
+	lsGlobalDDoSThresholdRE = regexp.MustCompile(lsPrefix + `config\.setup\.ike\.ddos_threshold=(\d+)$`)
+	lsGlobalMaxHalfOpenRE   = regexp.MustCompile(lsPrefix + `config\.setup\.ike\.max_halfopen=(\d+)$`)
 )
 
-var lsStatsRE = regexp.MustCompile(`IKE SAs: total\((\d+)\), half-open\((\d+)\)`)
+var (
+	lsVersionRE   = regexp.MustCompile(`pluto_version=([^,]+),`)
+	lsDDoSRE      = regexp.MustCompile(`ddos-cookies-threshold=(\d+), ddos-max-halfopen=(\d+), ddos-mode=(\w+)`)
+	lsStateInfoRE = regexp.MustCompile(`State Information: DDoS cookies (not required|required)`)
+	lsStatsRE     = regexp.MustCompile(`IKE SAs: total\((\d+)\), half-open\((\d+)\)`)
+	lsInterfaceRE = regexp.MustCompile(lsPrefix + `interface ([^/ ]+)(?:/\S+)?(?: (?:UDP|TCP))? \[?(` + lsIPAddrPart + `)]?[:@](\d+)$`)
+)
 
 func (e *Exporter) scrapeLibreswan(b []byte) (m metrics, ok bool) {
+	m.Daemon.Implementation = "libreswan"
 	ikeSAs := make(map[string]*ikeSA)
 	childSAs := make(map[string]*childSA)
 	localTS := make(map[string]string)
 	remoteTS := make(map[string]string)
+	conns := make(map[string]*connection)
+	reqIDs := make(map[string]uint32)
 	lines := strings.Split(string(b)+"\n", "\n")
 	for i := 0; i < len(lines); i++ {
 		if matches := findNamedSubmatch(lsConnRE, lines[i]); matches != nil {
@@ -135,6 +303,32 @@ func (e *Exporter) scrapeLibreswan(b []byte) (m metrics, ok bool) {
 					ChildSAs:   make(map[string]*childSA),
 				}
 			}
+			if ikeSA, ok := ikeSAs[name]; ok && matches["coninst"] == "" && conns[name] == nil {
+				// Instances are runtime copies of the template connection
+				// so only the latter is reported as configured.
+				conns[name] = &connection{
+					Name:       name,
+					LocalHost:  ikeSA.LocalHost,
+					LocalID:    ikeSA.LocalID,
+					RemoteHost: ikeSA.RemoteHost,
+					RemoteID:   ikeSA.RemoteID,
+				}
+				if matches := lsRoutingRE.FindStringSubmatch(s); matches != nil {
+					conns[name].Routing = matches[1]
+				}
+				m.Connections = append(m.Connections, conns[name])
+			} else if matches := lsReqIDRE.FindStringSubmatch(s); matches != nil {
+				n, _ := strconv.ParseUint(matches[1], 10, 32)
+				reqIDs[name] = uint32(n)
+			} else if conn, ok := conns[name]; ok {
+				if matches := lsAuthRE.FindStringSubmatch(s); matches != nil {
+					conn.LocalAuth = matches[1]
+					conn.RemoteAuth = matches[2]
+				} else if matches := lsPolicyRE.FindStringSubmatch(s); matches != nil {
+					conn.Policy = matches[1]
+					conn.Version = lsPolicyVersion(matches[1])
+				}
+			}
 		} else if matches := findNamedSubmatch(lsStateRE, lines[i]); matches != nil {
 			name := matches["conname"] + matches["coninst"]
 			key := matches["prefix"]
@@ -161,19 +355,30 @@ func (e *Exporter) scrapeLibreswan(b []byte) (m metrics, ok bool) {
 							ikeSA.ChildSAs[fmt.Sprintf("%s-%d", childSAs[key].Name, childSAs[key].UID)] = childSAs[key]
 						}
 						if m := lsStateNameRE.FindStringSubmatch(s); m != nil {
-							childSAs[key].State = m[1]
+							childSAs[key].State = lsStateName(m[1])
 							if ikeSA, ok := ikeSAs[name]; ok {
-								if strings.HasPrefix(m[1], "STATE_V2_") {
+								if strings.HasPrefix(childSAs[key].State, "STATE_V2_") {
 									ikeSA.Version = 2
 								} else {
 									ikeSA.Version = 1
 								}
 							}
 						}
+						// SPIs are printed as "esp.b129f1f7@192.1.2.45", the address
+						// is the SA destination so local ones are inbound.
 						for _, m := range lsSPIRE.FindAllStringSubmatch(s, -1) {
-							if m[1] == "tun" {
+							switch m[1] {
+							case "tun":
 								childSAs[key].Mode = "TUNNEL"
-								break
+							case "esp", "ah":
+								if childSAs[key].Mode == "" {
+									childSAs[key].Mode = "TRANSPORT"
+								}
+								if ikeSA, ok := ikeSAs[name]; ok && m[3] == ikeSA.LocalHost {
+									childSAs[key].SPIIn = m[2]
+								} else {
+									childSAs[key].SPIOut = m[2]
+								}
 							}
 						}
 						for _, m := range lsTrafficRE.FindAllStringSubmatch(s, -1) {
@@ -205,18 +410,32 @@ func (e *Exporter) scrapeLibreswan(b []byte) (m metrics, ok bool) {
 								ikeSA.RemoteXAuthID = m[1]
 							}
 						}
+						if m := lsStateNameRE.FindStringSubmatch(s); m != nil {
+							childSAs[key].Events = lsEvents(s)
+							newest := lsNewestRE.MatchString(s)
+							childSAs[key].Newest = &newest
+							childSAs[key].Idle = lsIdle(s)
+						}
 					} else {
 						if ikeSA, ok := ikeSAs[name]; ok {
 							ikeSA.UID = uint32(n)
 						}
 						if m := lsStateNameRE.FindStringSubmatch(s); m != nil {
 							if ikeSA, ok := ikeSAs[name]; ok {
-								ikeSA.State = m[1]
-								if strings.HasPrefix(m[1], "STATE_V2_") {
+								ikeSA.State = lsStateName(m[1])
+								if strings.HasPrefix(ikeSA.State, "STATE_V2_") {
 									ikeSA.Version = 2
 								} else {
 									ikeSA.Version = 1
 								}
+								if m := lsStateRoleRE.FindStringSubmatch(ikeSA.State); m != nil {
+									initiator := m[1] == "I"
+									ikeSA.Initiator = &initiator
+								}
+								ikeSA.Events = lsEvents(s)
+								newest := lsNewestRE.MatchString(s)
+								ikeSA.Newest = &newest
+								ikeSA.Idle = lsIdle(s)
 							}
 						}
 					}
@@ -225,6 +444,23 @@ func (e *Exporter) scrapeLibreswan(b []byte) (m metrics, ok bool) {
 					break
 				}
 			}
+		} else if matches := lsVersionRE.FindStringSubmatch(lines[i]); matches != nil {
+			m.Daemon.Version = matches[1]
+		} else if matches := lsDDoSRE.FindStringSubmatch(lines[i]); matches != nil {
+			threshold, _ := strconv.ParseUint(matches[1], 10, 64)
+			maxHalfOpen, _ := strconv.ParseUint(matches[2], 10, 64)
+			m.Stats.DDoS.CookiesThreshold = &threshold
+			m.Stats.DDoS.MaxHalfOpen = &maxHalfOpen
+			m.Stats.DDoS.Mode = matches[3]
+		} else if matches := lsStateInfoRE.FindStringSubmatch(lines[i]); matches != nil {
+			required := matches[1] == "required"
+			m.Stats.DDoS.CookiesRequired = &required
+		} else if matches := lsInterfaceRE.FindStringSubmatch(lines[i]); matches != nil {
+			m.Listening = append(m.Listening, listeningAddress{
+				Address:   matches[2],
+				Port:      matches[3],
+				Interface: matches[1],
+			})
 		} else if matches := lsStatsRE.FindStringSubmatch(lines[i]); matches != nil {
 			n, _ := strconv.ParseUint(matches[1], 10, 64)
 			m.Stats.IKESAs.Total = n
@@ -234,13 +470,228 @@ func (e *Exporter) scrapeLibreswan(b []byte) (m metrics, ok bool) {
 	}
 	for _, ikeSA := range ikeSAs {
 		if ikeSA.UID > 0 {
+			for _, childSA := range ikeSA.ChildSAs {
+				if reqID, ok := reqIDs[childSA.Name]; ok {
+					childSA.ReqID = &reqID
+				}
+			}
 			m.IKESAs = append(m.IKESAs, ikeSA)
 		}
 	}
+	e.roles.update(m.IKESAs)
 	ok = true
 	return
 }
 
+// roleTracker remembers the roles of IKE SAs as libreswan only tells them
+// by the state names of the SAs being negotiated, so an IKE SA keeps its
+// role once established.
+type roleTracker struct {
+	mu    sync.Mutex
+	roles map[uint32]bool
+}
+
+// update sets the remembered roles of the IKE SAs in the states without
+// a role. Only the IKE SAs of the scrape are remembered as serial numbers
+// aren't reused.
+func (r *roleTracker) update(ikeSAs []*ikeSA) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	roles := make(map[uint32]bool, len(ikeSAs))
+	for _, sa := range ikeSAs {
+		if sa.UID == 0 {
+			continue
+		}
+		if sa.Initiator == nil {
+			initiator, ok := r.roles[sa.UID]
+			if !ok {
+				continue
+			}
+			sa.Initiator = &initiator
+		}
+		roles[sa.UID] = *sa.Initiator
+	}
+	r.roles = roles
+}
+
+// scrapeLibreswanOptional merges the output of the configured optional
+// libreswan commands into m.
+func (e *Exporter) scrapeLibreswanOptional(m *metrics) {
+	for _, c := range []struct {
+		args   []string
+		scrape func(e *Exporter, m *metrics, b []byte)
+	}{
+		{e.trafficStatusCmd, (*Exporter).scrapeLibreswanTrafficStatus},
+		{e.addressPoolStatusCmd, (*Exporter).scrapeLibreswanAddressPoolStatus},
+		{e.globalStatusCmd, (*Exporter).scrapeLibreswanGlobalStatus},
+	} {
+		if len(c.args) == 0 {
+			continue
+		}
+		if output, ok := e.execOptional(c.args); ok {
+			c.scrape(e, m, output)
+		}
+	}
+}
+
+// scrapeLibreswanTrafficStatus merges the "ipsec whack --trafficstatus"
+// output into m: exact byte counts, install times, XAuth usernames,
+// IKE identities and leases.
+func (e *Exporter) scrapeLibreswanTrafficStatus(m *metrics, b []byte) {
+	type childSARef struct {
+		ikeSA   *ikeSA
+		childSA *childSA
+	}
+	// Child SA serial numbers are unique across the IKE SAs
+	childSAs := make(map[uint32]childSARef)
+	for _, ikeSA := range m.IKESAs {
+		for _, childSA := range ikeSA.ChildSAs {
+			childSAs[childSA.UID] = childSARef{ikeSA: ikeSA, childSA: childSA}
+		}
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		matches := findNamedSubmatch(lsTrafficStatusRE, line)
+		if matches == nil {
+			continue
+		}
+		n, _ := strconv.ParseUint(matches["serialno"], 10, 32)
+		ref, ok := childSAs[uint32(n)]
+		if !ok {
+			continue
+		}
+		ikeSA, childSA := ref.ikeSA, ref.childSA
+		childSA.InBytes, _ = strconv.ParseUint(matches["inbytes"], 10, 64)
+		childSA.OutBytes, _ = strconv.ParseUint(matches["outbytes"], 10, 64)
+		if childSA.Protocol == "" {
+			childSA.Protocol = matches["type"]
+		}
+		if addTime, _ := strconv.ParseInt(matches["addtime"], 10, 64); addTime > 0 {
+			installed := now().Unix() - addTime
+			childSA.Installed = &installed
+		}
+		if matches["username"] != "" {
+			ikeSA.RemoteXAuthID = matches["username"]
+		}
+		if id := matches["id"]; id != "" && ikeSA.RemoteID == "" {
+			ikeSA.RemoteID = strings.TrimPrefix(id, "@")
+		}
+		if lease := matches["lease"]; lease != "" && !containsString(ikeSA.RemoteVIPs, lease) {
+			ikeSA.RemoteVIPs = append(ikeSA.RemoteVIPs, lease)
+		}
+	}
+}
+
+// scrapeLibreswanAddressPoolStatus fills m pools from the
+// "ipsec whack --addresspoolstatus" output. Leases that are not in use
+// are lingering for the peer to reconnect and are reported as offline.
+func (e *Exporter) scrapeLibreswanAddressPoolStatus(m *metrics, b []byte) {
+	for _, line := range strings.Split(string(b), "\n") {
+		matches := findNamedSubmatch(lsAddressPoolRE, line)
+		if matches == nil {
+			continue
+		}
+		size, _ := strconv.ParseUint(matches["size"], 10, 64)
+		leases, _ := strconv.ParseUint(matches["leases"], 10, 64)
+		inUse, _ := strconv.ParseUint(matches["inuse"], 10, 64)
+		offline := uint64(0)
+		if leases > inUse {
+			offline = leases - inUse
+		}
+		m.Pools = append(m.Pools, pool{
+			Name:    matches["range"],
+			Address: matches["start"],
+			Size:    size,
+			Online:  inUse,
+			Offline: offline,
+		})
+	}
+}
+
+// scrapeLibreswanGlobalStatus merges the "ipsec whack --globalstatus"
+// output into m: the current state counts by type and by IKE category.
+// DDoS thresholds are only taken from it if the status lacks them.
+func (e *Exporter) scrapeLibreswanGlobalStatus(m *metrics, b []byte) {
+	for _, line := range strings.Split(string(b), "\n") {
+		if matches := lsGlobalStatesRE.FindStringSubmatch(line); matches != nil {
+			if m.Stats.States == nil {
+				m.Stats.States = make(map[string]uint64)
+			}
+			m.Stats.States[matches[1]], _ = strconv.ParseUint(matches[2], 10, 64)
+		} else if matches := lsGlobalIKEStatesRE.FindStringSubmatch(line); matches != nil {
+			if m.Stats.IKEStates == nil {
+				m.Stats.IKEStates = make(map[string]uint64)
+			}
+			m.Stats.IKEStates[matches[1]], _ = strconv.ParseUint(matches[2], 10, 64)
+		} else if matches := lsGlobalDDoSThresholdRE.FindStringSubmatch(line); matches != nil && m.Stats.DDoS.CookiesThreshold == nil {
+			n, _ := strconv.ParseUint(matches[1], 10, 64)
+			m.Stats.DDoS.CookiesThreshold = &n
+		} else if matches := lsGlobalMaxHalfOpenRE.FindStringSubmatch(line); matches != nil && m.Stats.DDoS.MaxHalfOpen == nil {
+			n, _ := strconv.ParseUint(matches[1], 10, 64)
+			m.Stats.DDoS.MaxHalfOpen = &n
+		}
+	}
+}
+
+// lsPolicyVersion returns the IKE version allowed by the connection policy
+// or 0 if it's unknown.
+func lsPolicyVersion(policy string) uint8 {
+	flags := make(map[string]bool)
+	for _, flag := range strings.Split(policy, "+") {
+		flags[flag] = true
+	}
+	switch {
+	case flags["IKEv2"]:
+		return 2
+	case flags["IKEv1"], flags["IKEV1_ALLOW"]:
+		return 1
+	case flags["IKEV2_ALLOW"]:
+		return 2
+	}
+	return 0
+}
+
+// lsEvents returns the pending state events, like "EVENT_SA_REPLACE in 3326s"
+// or "REKEY in 27848s" in libreswan 5.x, by their lowercased names without
+// the "EVENT_" prefixes.
+func lsEvents(s string) map[string]int64 {
+	events := make(map[string]int64)
+	for _, m := range lsEventRE.FindAllStringSubmatch(s, -1) {
+		events[strings.ToLower(m[1])], _ = strconv.ParseInt(m[2], 10, 64)
+	}
+	return events
+}
+
+// lsIdle returns whether the state has no pending crypto or DNS work, which
+// libreswan prints as the "idle" marker instead of "crypto_calculating" or
+// "crypto/DNS-lookup". Unlike the events it isn't a timer so it's exported
+// as a flag rather than an event.
+func lsIdle(s string) *bool {
+	m := lsIdleRE.FindStringSubmatch(s)
+	if m == nil {
+		return nil
+	}
+	idle := m[1] == "idle"
+	return &idle
+}
+
+// lsStateName returns the state name as printed by libreswan before 5.x
+// which dropped the "STATE_" and "STATE_V2_" prefixes.
+func lsStateName(s string) string {
+	if !strings.HasPrefix(s, "STATE_") {
+		if _, ok := lsStates["STATE_V2_"+s]; ok {
+			s = "STATE_V2_" + s
+		} else if _, ok := lsStateAliases["STATE_V2_"+s]; ok {
+			s = "STATE_V2_" + s
+		} else {
+			s = "STATE_" + s
+		}
+	}
+	if alias, ok := lsStateAliases[s]; ok {
+		return alias
+	}
+	return s
+}
+
 func findNamedSubmatch(re *regexp.Regexp, s string) map[string]string {
 	m := re.FindStringSubmatch(s)
 	if m == nil {
@@ -260,4 +711,26 @@ func init() {
 		ikeSAStates[k] = v
 		childSAStates[k] = v
 	}
+	ikeStates, childStates := make(map[string]float64), make(map[string]float64)
+	for _, k := range lsIKESAStates {
+		ikeStates[k] = lsStates[k]
+	}
+	for _, k := range lsChildSAStates {
+		childStates[k] = lsStates[k]
+	}
+	ikeSAKnownStates["libreswan"] = ikeStates
+	childSAKnownStates["libreswan"] = childStates
+	for k, v := range lsStatuses {
+		ikeSAStatuses[k] = v
+		childSAStatuses[k] = v
+	}
+}
+
+func containsString(a []string, s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
 }
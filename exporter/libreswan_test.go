@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -14,7 +15,16 @@ import (
 	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
+var lsOptionalScrapers = map[string]func(e *Exporter, m *metrics, b []byte){
+	"trafficstatus":     (*Exporter).scrapeLibreswanTrafficStatus,
+	"addresspoolstatus": (*Exporter).scrapeLibreswanAddressPoolStatus,
+	"globalstatus":      (*Exporter).scrapeLibreswanGlobalStatus,
+}
+
 func TestExporter_scrapeLibreswan(t *testing.T) {
+	// Traffic status fixtures install child SAs at 1636802014
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return time.Unix(1636802014+123, 0).UTC() }
 	files, err := filepath.Glob("testdata/libreswan/*-command.txt")
 	if err != nil {
 		panic("failed to list test files: " + err.Error())
@@ -29,7 +39,24 @@ func TestExporter_scrapeLibreswan(t *testing.T) {
 			if err != nil {
 				t.Fatalf("New() = _, %v; want nil", err)
 			}
-			exporter.scrape = func(e *Exporter) (m metrics, ok bool) { return e.scrapeLibreswan(in) }
+			optional := make(map[string][]byte)
+			for name := range lsOptionalScrapers {
+				b, err := ioutil.ReadFile(strings.Replace(file, "-command.txt", "-"+name+".txt", 1))
+				if err != nil && !os.IsNotExist(err) {
+					panic("failed to read " + name + " for " + file + ": " + err.Error())
+				}
+				if b != nil {
+					optional[name] = b
+				}
+			}
+			exporter.scrape = func(e *Exporter) (m metrics, ok bool) {
+				if m, ok = e.scrapeLibreswan(in); ok {
+					for name, b := range optional {
+						lsOptionalScrapers[name](e, &m, b)
+					}
+				}
+				return
+			}
 			outFile := strings.Replace(file, "-command.txt", "-metrics.txt", 1)
 			if _, err := os.Stat(outFile); err == nil {
 				out, err := ioutil.ReadFile(outFile)
@@ -58,11 +85,165 @@ func TestExporter_Collect_Libreswan(t *testing.T) {
 	if err != nil {
 		panic("failed to read testdata/libreswan/metrics-integration.txt: " + err.Error())
 	}
+	metricNames := []string{
+		"ipsec_child_sa_bytes_in",
+		"ipsec_child_sa_bytes_out",
+		"ipsec_child_sa_newest",
+		"ipsec_child_sa_state",
+		"ipsec_connection_up",
+		"ipsec_daemon_info",
+		"ipsec_ddos_cookies_required",
+		"ipsec_ddos_cookies_threshold",
+		"ipsec_ddos_max_half_open_ike_sas",
+		"ipsec_ddos_mode",
+		"ipsec_half_open_ike_sas",
+		"ipsec_ike_sa_newest",
+		"ipsec_ike_sa_state",
+		"ipsec_ike_sa_tasks",
+		"ipsec_ike_sas",
+		"ipsec_up",
+	}
 	exporter, err := New(CollectorIpsec, nil, time.Second, cmd, log.NewNopLogger())
 	if err != nil {
 		t.Fatalf("New() = _, %v; want nil", err)
 	}
-	if err := testutil.CollectAndCompare(&redactor{exporter}, bytes.NewReader(b)); err != nil {
+	if err := testutil.CollectAndCompare(&redactor{exporter}, bytes.NewReader(b), metricNames...); err != nil {
 		t.Errorf("testutil.CollectAndCompare() = %v; want nil", err)
 	}
 }
+
+func TestLsStateName(t *testing.T) {
+	tests := map[string]string{
+		"STATE_MAIN_R3":               "STATE_MAIN_R3",
+		"STATE_V2_ESTABLISHED_IKE_SA": "STATE_V2_ESTABLISHED_IKE_SA",
+		"STATE_V2_IKE_SA_INIT_I":      "STATE_V2_PARENT_I1",
+		"QUICK_R2":                    "STATE_QUICK_R2",
+		"ESTABLISHED_CHILD_SA":        "STATE_V2_ESTABLISHED_CHILD_SA",
+		"IKE_AUTH_I":                  "STATE_V2_PARENT_I2",
+		"UNDEFINED":                   "STATE_UNDEFINED",
+	}
+	for s, want := range tests {
+		if got := lsStateName(s); got != want {
+			t.Errorf("lsStateName(%q) = %q; want %q", s, got, want)
+		}
+	}
+}
+
+func TestLsSAStates(t *testing.T) {
+	types := make(map[string]int)
+	for _, states := range [][]string{lsIKESAStates, lsChildSAStates} {
+		for _, state := range states {
+			if _, ok := lsStates[state]; !ok {
+				t.Errorf("lsStates[%q] is missing", state)
+			}
+			types[state]++
+		}
+	}
+	for state := range lsStates {
+		if n := types[state]; n != 1 && state != "STATE_INFO" && state != "STATE_INFO_PROTECTED" {
+			t.Errorf("%q is listed in %d SA types; want 1", state, n)
+		}
+	}
+}
+
+func TestLsIdle(t *testing.T) {
+	tests := map[string]*bool{
+		`#1: "host-host":500 ESTABLISHED_IKE_SA (established IKE SA); REKEY in 27848s; newest; idle;`:                          newBool(true),
+		`#3: "host-host":500 STATE_V2_PARENT_I1 (sent IKE_SA_INIT request); EVENT_RETRANSMIT in 1s; crypto_calculating;`:       newBool(false),
+		`#4: "road"[1] 192.1.3.209:500 STATE_MAIN_R1 (sent MR1, expecting MI2); EVENT_SA_REPLACE in 3272s; crypto/DNS-lookup;`: newBool(false),
+		`#5: "host-host":500 STATE_V2_PARENT_I1 (sent IKE_SA_INIT request); EVENT_RETRANSMIT in 1s;`:                           nil,
+	}
+	for s, want := range tests {
+		got := lsIdle(s)
+		if (got == nil) != (want == nil) || (got != nil && *got != *want) {
+			t.Errorf("lsIdle(%q) = %v; want %v", s, got, want)
+		}
+	}
+}
+
+func TestReLSMarker(t *testing.T) {
+	for _, s := range []string{"000 Connection list:\n", "Connection list:\n"} {
+		if !reLSMarker.MatchString(s) {
+			t.Errorf("reLSMarker.MatchString(%q) = false; want true", s)
+		}
+	}
+}
+
+func TestLsInterfaceRE(t *testing.T) {
+	tests := map[string][]string{
+		"000 interface eth0/eth0 192.0.2.254:4500":     {"eth0", "192.0.2.254", "4500"},
+		"000 interface eth0/eth0 192.0.2.254@500":      {"eth0", "192.0.2.254", "500"},
+		"interface eth0 UDP 192.1.3.209:500":           {"eth0", "192.1.3.209", "500"},
+		"interface eth1 TCP [2001:db8:1:2::23]:4500":   {"eth1", "2001:db8:1:2::23", "4500"},
+		"000 interface eth1/eth1 2001:db8:1:2::23:500": {"eth1", "2001:db8:1:2::23", "500"},
+		"000 using kernel interface: xfrm":             nil,
+	}
+	for s, want := range tests {
+		matches := lsInterfaceRE.FindStringSubmatch(s)
+		if matches == nil {
+			if want != nil {
+				t.Errorf("lsInterfaceRE.FindStringSubmatch(%q) = nil; want %q", s, want)
+			}
+			continue
+		}
+		if got := matches[1:]; !reflect.DeepEqual(got, want) {
+			t.Errorf("lsInterfaceRE.FindStringSubmatch(%q)[1:] = %q; want %q", s, got, want)
+		}
+	}
+}
+
+func TestExporter_scrapeLibreswan_SPIs(t *testing.T) {
+	in, err := ioutil.ReadFile("testdata/libreswan/6-command.txt")
+	if err != nil {
+		panic("failed to read testdata/libreswan/6-command.txt: " + err.Error())
+	}
+	exporter, err := New(CollectorIpsec, nil, 0, nil, log.NewNopLogger())
+	if err != nil {
+		t.Fatalf("New() = _, %v; want nil", err)
+	}
+	m, ok := exporter.scrapeLibreswan(in)
+	if !ok || len(m.IKESAs) != 1 || len(m.IKESAs[0].ChildSAs) != 1 {
+		t.Fatalf("scrapeLibreswan() = %+v, %v; want 1 IKE SA with 1 child SA", m, ok)
+	}
+	for _, childSA := range m.IKESAs[0].ChildSAs {
+		if childSA.SPIIn != "3a4f6e1b" || childSA.SPIOut != "3b7bb7e2" {
+			t.Errorf("SPIs = %q, %q; want %q, %q", childSA.SPIIn, childSA.SPIOut, "3a4f6e1b", "3b7bb7e2")
+		}
+	}
+}
+
+func TestExporter_execOptional_Timeout(t *testing.T) {
+	exporter, err := New(CollectorIpsec, nil, 100*time.Millisecond, nil, log.NewNopLogger())
+	if err != nil {
+		t.Fatalf("New() = _, %v; want nil", err)
+	}
+	start := time.Now()
+	if _, ok := exporter.execOptional([]string{"sleep", "5"}); ok {
+		t.Error("execOptional() = _, true; want false")
+	}
+	if d := time.Since(start); d >= 5*time.Second {
+		t.Errorf("execOptional() took %v; want less than 5s", d)
+	}
+}
+
+func TestRoleTracker(t *testing.T) {
+	var r roleTracker
+	r.update([]*ikeSA{
+		{UID: 1, State: "STATE_V2_PARENT_I2", Initiator: newBool(true)},
+		{UID: 2, State: "STATE_V2_PARENT_R1", Initiator: newBool(false)},
+	})
+	ikeSAs := []*ikeSA{
+		{UID: 1, State: "STATE_V2_ESTABLISHED_IKE_SA"},
+		{UID: 3, State: "STATE_V2_ESTABLISHED_IKE_SA"},
+	}
+	r.update(ikeSAs)
+	if got, want := ikeSAs[0].Role(), "initiator"; got != want {
+		t.Errorf("Role() = %q; want %q", got, want)
+	}
+	if got, want := ikeSAs[1].Role(), ""; got != want {
+		t.Errorf("Role() = %q; want %q", got, want)
+	}
+	if _, ok := r.roles[2]; ok {
+		t.Errorf("roles[2] is remembered after the IKE SA is gone")
+	}
+}
@@ -1,80 +1,210 @@
 package exporter
 
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
 type metrics struct {
-	Stats  stats
-	Pools  []pool
-	IKESAs []*ikeSA
+	Daemon      daemon             `json:"daemon"`
+	Stats       stats              `json:"stats"`
+	Listening   []listeningAddress `json:"listening,omitempty"`
+	Pools       []pool             `json:"pools,omitempty"`
+	Connections []*connection      `json:"connections,omitempty"`
+	Policies    []policy           `json:"policies,omitempty"`
+	IKESAs      []*ikeSA           `json:"ike_sas,omitempty"`
+}
+
+type daemon struct {
+	Implementation string `json:"implementation"`
+	Version        string `vici:"version" json:"version"`
+	SysName        string `vici:"sysname" json:"sysname"`
+	Release        string `vici:"release" json:"release"`
+	Machine        string `vici:"machine" json:"machine"`
 }
 
 type stats struct {
-	Uptime    uptime   `vici:"uptime"`
-	Workers   *workers `vici:"workers"`
-	Queues    *queues  `vici:"queues"`
-	Scheduled *uint64  `vici:"scheduled"`
-	IKESAs    ikeSAs   `vici:"ikesas"`
+	Uptime    uptime            `vici:"uptime" json:"uptime"`
+	Workers   *workers          `vici:"workers" json:"workers,omitempty"`
+	Queues    *queues           `vici:"queues" json:"queues,omitempty"`
+	Scheduled *uint64           `vici:"scheduled" json:"scheduled,omitempty"`
+	IKESAs    ikeSAs            `vici:"ikesas" json:"ike_sas"`
+	Plugins   []string          `vici:"plugins" json:"plugins,omitempty"`
+	Mem       *mem              `vici:"mem" json:"mem,omitempty"`
+	Mallinfo  *mallinfo         `vici:"mallinfo" json:"mallinfo,omitempty"`
+	States    map[string]uint64 `json:"states,omitempty"`
+	IKEStates map[string]uint64 `json:"ike_states,omitempty"`
+	DDoS      ddos              `json:"ddos"`
 }
 
 type uptime struct {
-	Since string `vici:"since"`
+	Running string    `vici:"running" json:"running"`
+	Since   string    `vici:"since" json:"since"`
+	Started time.Time `json:"-"`
 }
 
 type workers struct {
-	Total  uint64 `vici:"total"`
-	Idle   uint64 `vici:"idle"`
-	Active queues `vici:"active"`
+	Total  uint64 `vici:"total" json:"total"`
+	Idle   uint64 `vici:"idle" json:"idle"`
+	Active queues `vici:"active" json:"active"`
 }
 
 type queues struct {
-	Critical uint64 `vici:"critical"`
-	High     uint64 `vici:"high"`
-	Medium   uint64 `vici:"medium"`
-	Low      uint64 `vici:"low"`
+	Critical uint64 `vici:"critical" json:"critical"`
+	High     uint64 `vici:"high" json:"high"`
+	Medium   uint64 `vici:"medium" json:"medium"`
+	Low      uint64 `vici:"low" json:"low"`
 }
 
 func (q queues) Total() uint64 { return q.Critical + q.High + q.Medium + q.Low }
 
 type ikeSAs struct {
-	Total    uint64 `vici:"total"`
-	HalfOpen uint64 `vici:"half-open"`
+	Total    uint64 `vici:"total" json:"total"`
+	HalfOpen uint64 `vici:"half-open" json:"half_open"`
+}
+
+type mem struct {
+	Total  uint64 `vici:"total" json:"total"`
+	Allocs uint64 `vici:"allocs" json:"allocs"`
+}
+
+type mallinfo struct {
+	Sbrk uint64 `vici:"sbrk" json:"sbrk"`
+	Mmap uint64 `vici:"mmap" json:"mmap"`
+	Used uint64 `vici:"used" json:"used"`
+	Free uint64 `vici:"free" json:"free"`
+}
+
+type ddos struct {
+	CookiesThreshold *uint64 `json:"cookies_threshold,omitempty"`
+	MaxHalfOpen      *uint64 `json:"max_half_open,omitempty"`
+	Mode             string  `json:"mode"`
+	CookiesRequired  *bool   `json:"cookies_required,omitempty"`
+}
+
+type listeningAddress struct {
+	Address   string `json:"address"`
+	Port      string `json:"port"`
+	Interface string `json:"interface"`
 }
 
 type pool struct {
-	Name    string
-	Address string `vici:"base"`
-	Size    uint64 `vici:"size"`
-	Online  uint64 `vici:"online"`
-	Offline uint64 `vici:"offline"`
+	Name    string `json:"name"`
+	Address string `vici:"base" json:"address"`
+	Size    uint64 `vici:"size" json:"size"`
+	Online  uint64 `vici:"online" json:"online"`
+	Offline uint64 `vici:"offline" json:"offline"`
+}
+
+// ikeVersionAny is the version of connections allowing both IKEv1 and IKEv2.
+const ikeVersionAny uint8 = 3
+
+type connection struct {
+	Name       string             `json:"name"`
+	Version    uint8              `json:"version"`
+	LocalHost  string             `json:"local_host"`
+	LocalID    string             `json:"local_id"`
+	RemoteHost string             `json:"remote_host"`
+	RemoteID   string             `json:"remote_id"`
+	LocalAuth  string             `json:"local_auth"`
+	RemoteAuth string             `json:"remote_auth"`
+	Routing    string             `json:"routing"`
+	Policy     string             `json:"policy"`
+	DPDDelay   *uint64            `json:"dpd_delay,omitempty"`
+	Children   []*connectionChild `json:"children,omitempty"`
+}
+
+type connectionChild struct {
+	Name      string   `json:"name"`
+	Mode      string   `json:"mode"`
+	LocalTS   []string `json:"local_ts,omitempty"`
+	RemoteTS  []string `json:"remote_ts,omitempty"`
+	DPDAction string   `json:"dpd_action"`
+}
+
+type policy struct {
+	Name       string   `vici:"child" json:"name"`
+	Connection string   `vici:"ike" json:"connection"`
+	Mode       string   `vici:"mode" json:"mode"`
+	LocalTS    []string `vici:"local-ts" json:"local_ts,omitempty"`
+	RemoteTS   []string `vici:"remote-ts" json:"remote_ts,omitempty"`
+}
+
+// Type returns "pass" or "drop" for shunt policies or "trap" otherwise.
+func (p policy) Type() string {
+	switch p.Mode {
+	case "PASS", "DROP":
+		return strings.ToLower(p.Mode)
+	default:
+		return "trap"
+	}
 }
 
 type ikeSA struct {
-	Name          string
-	UID           uint32              `vici:"uniqueid"`
-	Version       uint8               `vici:"version"`
-	State         string              `vici:"state"`
-	LocalHost     string              `vici:"local-host"`
-	LocalID       string              `vici:"local-id"`
-	RemoteHost    string              `vici:"remote-host"`
-	RemoteID      string              `vici:"remote-id"`
-	RemoteXAuthID string              `vici:"remote-xauth-id"`
-	RemoteEAPID   string              `vici:"remote-eap-id"`
-	Established   *int64              `vici:"established"`
-	LocalVIPs     []string            `vici:"local-vips"`
-	RemoteVIPs    []string            `vici:"remote-vips"`
-	ChildSAs      map[string]*childSA `vici:"child-sas"`
+	Name          string              `json:"name"`
+	UID           uint32              `vici:"uniqueid" json:"uid"`
+	Version       uint8               `vici:"version" json:"version"`
+	State         string              `vici:"state" json:"state"`
+	Initiator     *bool               `vici:"initiator" json:"initiator,omitempty"`
+	InitiatorSPI  string              `vici:"initiator-spi" json:"initiator_spi"`
+	ResponderSPI  string              `vici:"responder-spi" json:"responder_spi"`
+	LocalHost     string              `vici:"local-host" json:"local_host"`
+	LocalID       string              `vici:"local-id" json:"local_id"`
+	RemoteHost    string              `vici:"remote-host" json:"remote_host"`
+	RemoteID      string              `vici:"remote-id" json:"remote_id"`
+	RemoteXAuthID string              `vici:"remote-xauth-id" json:"remote_xauth_id"`
+	RemoteEAPID   string              `vici:"remote-eap-id" json:"remote_eap_id"`
+	Established   *int64              `vici:"established" json:"established,omitempty"`
+	LocalVIPs     []string            `vici:"local-vips" json:"local_vips,omitempty"`
+	RemoteVIPs    []string            `vici:"remote-vips" json:"remote_vips,omitempty"`
+	TasksQueued   []string            `vici:"tasks-queued" json:"tasks_queued,omitempty"`
+	TasksActive   []string            `vici:"tasks-active" json:"tasks_active,omitempty"`
+	TasksPassive  []string            `vici:"tasks-passive" json:"tasks_passive,omitempty"`
+	ChildSAs      map[string]*childSA `vici:"child-sas" json:"child_sas,omitempty"`
+	Events        map[string]int64    `json:"events,omitempty"`
+	Newest        *bool               `json:"newest,omitempty"`
+	Idle          *bool               `json:"idle,omitempty"`
+}
+
+// Role returns "initiator" or "responder" depending on the local role
+// in the IKE SA or an empty string if it's unknown.
+func (sa *ikeSA) Role() string {
+	switch {
+	case sa.Initiator == nil:
+		return ""
+	case *sa.Initiator:
+		return "initiator"
+	default:
+		return "responder"
+	}
+}
+
+var instanceRE = regexp.MustCompile(`\[\d+]$`)
+
+// ConnectionName returns the name of the connection the IKE SA belongs to
+// without the libreswan instance number.
+func (sa *ikeSA) ConnectionName() string {
+	return instanceRE.ReplaceAllString(sa.Name, "")
 }
 
 type childSA struct {
-	Name       string   `vici:"name"`
-	UID        uint32   `vici:"uniqueid"`
-	ReqID      *uint32  `vici:"reqid"`
-	State      string   `vici:"state"`
-	Mode       string   `vici:"mode"`
-	Protocol   string   `vici:"protocol"`
-	InBytes    uint64   `vici:"bytes-in"`
-	InPackets  *uint64  `vici:"packets-in"`
-	OutBytes   uint64   `vici:"bytes-out"`
-	OutPackets *uint64  `vici:"packets-out"`
-	Installed  *int64   `vici:"install-time"`
-	LocalTS    []string `vici:"local-ts"`
-	RemoteTS   []string `vici:"remote-ts"`
+	Name       string           `vici:"name" json:"name"`
+	UID        uint32           `vici:"uniqueid" json:"uid"`
+	ReqID      *uint32          `vici:"reqid" json:"reqid,omitempty"`
+	State      string           `vici:"state" json:"state"`
+	Mode       string           `vici:"mode" json:"mode"`
+	Protocol   string           `vici:"protocol" json:"protocol"`
+	SPIIn      string           `vici:"spi-in" json:"spi_in"`
+	SPIOut     string           `vici:"spi-out" json:"spi_out"`
+	InBytes    uint64           `vici:"bytes-in" json:"in_bytes"`
+	InPackets  *uint64          `vici:"packets-in" json:"in_packets,omitempty"`
+	OutBytes   uint64           `vici:"bytes-out" json:"out_bytes"`
+	OutPackets *uint64          `vici:"packets-out" json:"out_packets,omitempty"`
+	Installed  *int64           `vici:"install-time" json:"installed,omitempty"`
+	LocalTS    []string         `vici:"local-ts" json:"local_ts,omitempty"`
+	RemoteTS   []string         `vici:"remote-ts" json:"remote_ts,omitempty"`
+	Events     map[string]int64 `json:"events,omitempty"`
+	Newest     *bool            `json:"newest,omitempty"`
+	Idle       *bool            `json:"idle,omitempty"`
 }
@@ -0,0 +1,45 @@
+package exporter
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+)
+
+type status struct {
+	metrics
+	Started *time.Time `json:"started,omitempty"`
+}
+
+// StatusHandler returns a handler serving the scraped daemon status as JSON.
+// IKE SAs can be filtered by the "connection" and "remote_host" query
+// parameters.
+func (e *Exporter) StatusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m, ok := e.scrape(e)
+		if !ok {
+			http.Error(w, "Failed to scrape the daemon status", http.StatusServiceUnavailable)
+			return
+		}
+		connection, remoteHost := r.URL.Query().Get("connection"), r.URL.Query().Get("remote_host")
+		if connection != "" || remoteHost != "" {
+			var ikeSAs []*ikeSA
+			for _, ikeSA := range m.IKESAs {
+				if (connection == "" || ikeSA.ConnectionName() == connection) && (remoteHost == "" || ikeSA.RemoteHost == remoteHost) {
+					ikeSAs = append(ikeSAs, ikeSA)
+				}
+			}
+			m.IKESAs = ikeSAs
+		}
+		s := status{metrics: m}
+		if started, _, ok := e.startTime(m.Stats.Uptime); ok {
+			s.Started = &started
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(s); err != nil {
+			level.Error(e.logger).Log("msg", "Failed to write status", "err", err)
+		}
+	})
+}
@@ -0,0 +1,137 @@
+package exporter
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+)
+
+func TestExporter_StatusHandler(t *testing.T) {
+	exporter, err := New(CollectorIpsec, nil, 0, nil, log.NewNopLogger())
+	if err != nil {
+		t.Fatalf("New() = _, %v; want nil", err)
+	}
+	exporter.scrape = func(e *Exporter) (m metrics, ok bool) {
+		return metrics{
+			Daemon: daemon{
+				Implementation: "libreswan",
+				Version:        "4.4",
+			},
+			Stats: stats{
+				IKESAs: ikeSAs{Total: 2},
+			},
+			Pools: []pool{
+				{Name: "pool", Address: "10.0.4.0/24", Size: 254, Online: 1},
+			},
+			IKESAs: []*ikeSA{
+				{
+					Name:       "named[1]",
+					UID:        1,
+					Version:    2,
+					State:      "STATE_V2_ESTABLISHED_IKE_SA",
+					RemoteHost: "10.0.3.1",
+					ChildSAs: map[string]*childSA{
+						"named-2": {
+							Name:     "named",
+							UID:      2,
+							State:    "STATE_V2_ESTABLISHED_CHILD_SA",
+							InBytes:  1,
+							OutBytes: 2,
+							LocalTS:  []string{"10.0.1.0/24"},
+							RemoteTS: []string{"10.0.2.0/24"},
+						},
+					},
+				},
+				{
+					Name:       "other",
+					UID:        3,
+					Version:    2,
+					State:      "STATE_V2_PARENT_I1",
+					RemoteHost: "10.0.3.2",
+				},
+			},
+		}, true
+	}
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{
+			query: "",
+			want: `{
+				"daemon": {"implementation": "libreswan", "version": "4.4", "sysname": "", "release": "", "machine": ""},
+				"stats": {"uptime": {"running": "", "since": ""}, "ike_sas": {"total": 2, "half_open": 0}, "ddos": {"mode": ""}},
+				"pools": [{"name": "pool", "address": "10.0.4.0/24", "size": 254, "online": 1, "offline": 0}],
+				"ike_sas": [
+					{
+						"name": "named[1]", "uid": 1, "version": 2, "state": "STATE_V2_ESTABLISHED_IKE_SA",
+						"initiator_spi": "", "responder_spi": "", "local_host": "", "local_id": "", "remote_host": "10.0.3.1", "remote_id": "",
+						"remote_xauth_id": "", "remote_eap_id": "",
+						"child_sas": {
+							"named-2": {
+								"name": "named", "uid": 2, "state": "STATE_V2_ESTABLISHED_CHILD_SA", "mode": "", "protocol": "",
+								"spi_in": "", "spi_out": "", "in_bytes": 1, "out_bytes": 2, "local_ts": ["10.0.1.0/24"], "remote_ts": ["10.0.2.0/24"]
+							}
+						}
+					},
+					{
+						"name": "other", "uid": 3, "version": 2, "state": "STATE_V2_PARENT_I1",
+						"initiator_spi": "", "responder_spi": "", "local_host": "", "local_id": "", "remote_host": "10.0.3.2", "remote_id": "",
+						"remote_xauth_id": "", "remote_eap_id": ""
+					}
+				]
+			}`,
+		},
+		{
+			query: "?connection=named&remote_host=10.0.3.1",
+			want:  `["named[1]"]`,
+		},
+		{
+			query: "?remote_host=10.0.3.2",
+			want:  `["other"]`,
+		},
+		{
+			query: "?connection=missing",
+			want:  `[]`,
+		},
+	}
+	for _, td := range tests {
+		t.Run(td.query, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			exporter.StatusHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/status"+td.query, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("StatusHandler() code = %d; want %d", rec.Code, http.StatusOK)
+			}
+			var got, want interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("json.Unmarshal() = %v; want nil", err)
+			}
+			if err := json.Unmarshal([]byte(td.want), &want); err != nil {
+				t.Fatalf("json.Unmarshal() = %v; want nil", err)
+			}
+			if _, ok := want.([]interface{}); ok {
+				// Only compare the IKE SA names
+				names := []interface{}{}
+				ikeSAs, _ := got.(map[string]interface{})["ike_sas"].([]interface{})
+				for _, ikeSA := range ikeSAs {
+					names = append(names, ikeSA.(map[string]interface{})["name"])
+				}
+				got = names
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("StatusHandler() = %s; want %s", rec.Body, td.want)
+			}
+		})
+	}
+
+	exporter.scrape = func(e *Exporter) (m metrics, ok bool) { return }
+	rec := httptest.NewRecorder()
+	exporter.StatusHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/status", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("StatusHandler() code = %d; want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
@@ -0,0 +1,76 @@
+package exporter
+
+import (
+	"io/ioutil"
+	"net"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+)
+
+// The status request is a struct stroke_msg_t from strongSwan
+// src/stroke/stroke_msg.h, as of strongSwan 5.x (e.g. 5.9.1 in Debian
+// bullseye):
+//
+//	struct stroke_msg_t {
+//		uint16_t length;
+//		enum { STR_INITIATE, ..., STR_STATUS_ALL, ... } type;
+//		int output_verbosity;
+//		union { ... };
+//		uint16_t buflen;
+//		char buffer[];
+//	};
+//
+// charon reads length first and then the rest of the message, so it must
+// cover the fixed part of the struct. All the strings are NULL for
+// STR_STATUS_ALL, so the union and buflen are zeroed.
+
+// strokeStatusAll is STR_STATUS_ALL, the 10th entry of the type enum.
+const strokeStatusAll = 9
+
+// strokeVerbosity is the output_verbosity the stroke tool sends by default.
+const strokeVerbosity = 1
+
+// strokeMsgSize is larger than the fixed part of struct stroke_msg_t on
+// all the supported architectures.
+const strokeMsgSize = 2048
+
+// Offsets of the struct stroke_msg_t fields: the enum is int aligned after
+// the 2 byte length.
+const (
+	strokeTypeOffset      = 4
+	strokeVerbosityOffset = 8
+)
+
+func (e *Exporter) scrapeStroke() (m metrics, ok bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	conn, err := net.DialTimeout("unix", e.strokeSocket, e.timeout)
+	if err != nil {
+		level.Error(e.logger).Log("msg", "Failed to connect to charon", "err", err)
+		return
+	}
+	defer conn.Close()
+	if e.timeout > 0 {
+		conn.SetDeadline(time.Now().Add(e.timeout))
+	}
+
+	msg := make([]byte, strokeMsgSize)
+	nativeEndian.PutUint16(msg, strokeMsgSize)
+	nativeEndian.PutUint32(msg[strokeTypeOffset:], strokeStatusAll)
+	nativeEndian.PutUint32(msg[strokeVerbosityOffset:], strokeVerbosity)
+	if _, err = conn.Write(msg); err != nil {
+		level.Error(e.logger).Log("msg", "Failed to send command", "cmd", "statusall", "err", err)
+		return
+	}
+	output, err := ioutil.ReadAll(conn)
+	if err != nil {
+		level.Error(e.logger).Log("msg", "Failed to read command response", "cmd", "statusall", "err", err)
+		return
+	}
+	if !reSSMarker.Match(output) {
+		level.Error(e.logger).Log("msg", "Failed to recognize command response", "cmd", "statusall", "output", output)
+		return
+	}
+	return e.scrapeStrongswan(output)
+}
@@ -0,0 +1,124 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// strokeStatusAllMsg is the statusall request sent by stroke of strongSwan
+// 5.9.1 on little-endian hosts: the length, STR_STATUS_ALL and
+// output_verbosity 1 followed by NULL strings.
+var strokeStatusAllMsg = append([]byte{
+	0x00, 0x08, 0x00, 0x00,
+	0x09, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x00, 0x00,
+}, make([]byte, 2036)...)
+
+func TestExporter_scrapeStroke(t *testing.T) {
+	in, err := ioutil.ReadFile("testdata/strongswan/1-command.txt")
+	if err != nil {
+		panic("failed to read testdata/strongswan/1-command.txt: " + err.Error())
+	}
+	out, err := ioutil.ReadFile("testdata/strongswan/1-metrics.txt")
+	if err != nil {
+		panic("failed to read testdata/strongswan/1-metrics.txt: " + err.Error())
+	}
+	dir, err := ioutil.TempDir("", "ipsec_exporter")
+	if err != nil {
+		panic("failed to create temp dir: " + err.Error())
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "charon.ctl")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		panic("failed to listen on " + socket + ": " + err.Error())
+	}
+	defer l.Close()
+	msgs := make(chan []byte, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		msg := make([]byte, 2)
+		if _, err := io.ReadFull(conn, msg); err != nil {
+			return
+		}
+		// charon rejects messages shorter than the fixed part of the struct
+		if nativeEndian.Uint16(msg) < 12 {
+			return
+		}
+		msg = append(msg, make([]byte, nativeEndian.Uint16(msg)-2)...)
+		if _, err := io.ReadFull(conn, msg[2:]); err != nil {
+			return
+		}
+		msgs <- msg
+		if nativeEndian.Uint32(msg[4:]) == 9 {
+			conn.Write(in)
+		}
+	}()
+
+	exporter, err := New(CollectorStroke, nil, 0, nil, log.NewNopLogger(), WithStrokeSocket(socket))
+	if err != nil {
+		t.Fatalf("New() = _, %v; want nil", err)
+	}
+	if err = testutil.CollectAndCompare(exporter, bytes.NewReader(out)); err != nil {
+		t.Errorf("testutil.CollectAndCompare() = %v; want nil", err)
+	}
+	msg := <-msgs
+	if nativeEndian != binary.LittleEndian {
+		t.Skip("skipping stroke message comparison on big-endian")
+	}
+	if !bytes.Equal(msg, strokeStatusAllMsg) {
+		t.Errorf("stroke message = % x; want % x", msg, strokeStatusAllMsg)
+	}
+}
+
+func TestExporter_scrapeStroke_Timeout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ipsec_exporter")
+	if err != nil {
+		panic("failed to create temp dir: " + err.Error())
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "charon.ctl")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		panic("failed to listen on " + socket + ": " + err.Error())
+	}
+	defer l.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		<-done
+	}()
+
+	exporter, err := New(CollectorStroke, nil, 100*time.Millisecond, nil, log.NewNopLogger(), WithStrokeSocket(socket))
+	if err != nil {
+		t.Fatalf("New() = _, %v; want nil", err)
+	}
+	expected := `
+# HELP ipsec_up Was the last scrape successful.
+# TYPE ipsec_up gauge
+ipsec_up 0
+`
+	if err := testutil.CollectAndCompare(exporter, strings.NewReader(expected)); err != nil {
+		t.Errorf("testutil.CollectAndCompare() = %v; want nil", err)
+	}
+}
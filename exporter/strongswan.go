@@ -9,7 +9,11 @@ import (
 
 const (
 	ssPrefixStatus = "Status of IKE charon daemon"
+	ssPrefixListen = "Listening IP addresses:"
 	ssPrefixPools  = "Virtual IP pools (size/online/offline):"
+	ssPrefixConns  = "Connections:"
+	ssPrefixRouted = "Routed Connections:"
+	ssPrefixShunts = "Shunted Connections:"
 	ssPrefixSA     = "Security Associations"
 )
 
@@ -37,19 +41,54 @@ var (
 		"DELETED":    9,
 		"DESTROYING": 10,
 	}
+	ssIKESAStatuses = map[string]float64{
+		"CREATED":     statusCreated,
+		"CONNECTING":  statusConnecting,
+		"ESTABLISHED": statusEstablished,
+		"PASSIVE":     statusEstablished,
+		"REKEYING":    statusRekeying,
+		"REKEYED":     statusDeleting,
+		"DELETING":    statusDeleting,
+		"DESTROYING":  statusDeleting,
+	}
+	ssChildSAStatuses = map[string]float64{
+		"CREATED":    statusCreated,
+		"ROUTED":     statusCreated,
+		"INSTALLING": statusConnecting,
+		"INSTALLED":  statusEstablished,
+		"UPDATING":   statusEstablished,
+		"REKEYING":   statusRekeying,
+		"REKEYED":    statusDeleting,
+		"RETRYING":   statusConnecting,
+		"DELETING":   statusDeleting,
+		"DELETED":    statusDeleting,
+		"DESTROYING": statusDeleting,
+	}
 )
 
 var (
-	ssUptimeRE           = regexp.MustCompile(`^  uptime: .+, since (.+)$`)
+	ssStatusRE           = regexp.MustCompile(`^Status of IKE charon daemon \(strongSwan ([^,]+), (\S+) ([^,]+), ([^)]+)\):$`)
+	ssUptimeRE           = regexp.MustCompile(`^  uptime: (.+), since (.+)$`)
+	ssMallocRE           = regexp.MustCompile(`^  malloc: sbrk (\d+), mmap (\d+), used (\d+), free (\d+)$`)
+	ssPluginsRE          = regexp.MustCompile(`^  loaded plugins: (.*)$`)
 	ssStatsRE            = regexp.MustCompile(`^  worker threads: (\d+) of (\d+) idle, (\d+)/(\d+)/(\d+)/(\d+) working, job queue: (\d+)/(\d+)/(\d+)/(\d+), scheduled: (\d+)$`)
+	ssListenRE           = regexp.MustCompile(`^  (\S+)$`)
 	ssPoolRE             = regexp.MustCompile(`^  (.+?): (\d+)/(\d+)/(\d+)$`)
+	ssConnPrefixRE       = regexp.MustCompile(`^\s*[^:\s]+:  `)
+	ssConnRE             = regexp.MustCompile(`^\s*([^:\s]+):  (\S+)\.\.\.(\S+)  IKEv([12/]+)(?:, dpddelay=(\d+)s)?`)
+	ssConnAuthRE         = regexp.MustCompile(`^\s*[^:\s]+:   (local|remote): +(?:\[([^]]*)] )?uses (.+?) authentication`)
+	ssConnChildRE        = regexp.MustCompile(`^\s*([^:\s]+):   child:  (.+) === (.+) ([A-Z_]+)(?:, dpdaction=(\S+))?$`)
+	ssRoutedRE           = regexp.MustCompile(`^\s*([^{]+){\d+}:  ROUTED, ([^,]+)`)
+	ssRoutedTSRE         = regexp.MustCompile(`^\s*[^{]+{\d+}:   (.+) === (.+)$`)
+	ssShuntRE            = regexp.MustCompile(`^\s*([^:\s]+):  (.+) === (.+) (PASS|DROP)$`)
 	ssSAHeaderRE         = regexp.MustCompile(`^Security Associations \((\d+) up, (\d+) connecting\):$`)
 	ssSAPrefixRE         = regexp.MustCompile(`^\s*([^\[]+)\[(\d+)]: `)
 	ssSAStatusRE         = regexp.MustCompile(`^([^ ]+) .+ ago, ([^\[]+)\[([^]]+)]\.\.\.([^\[]+)\[([^]]+)]$`)
-	ssSAVersionRE        = regexp.MustCompile(`^(.+) SPIs:`)
+	ssSASPIsRE           = regexp.MustCompile(`^(.+) SPIs: ([0-9a-f]+)_i(\*?) ([0-9a-f]+)_r(\*?)`)
+	ssSATasksRE          = regexp.MustCompile(`^Tasks (queued|active|passive): (.+)$`)
 	ssSARemoteIdentityRE = regexp.MustCompile(`^Remote (.+) identity: (.+)$`)
 	ssChildSAPrefixRE    = regexp.MustCompile(`^\s*([^{]+){(\d+)}:  `)
-	ssChildSAStatusRE    = regexp.MustCompile(`^([^,]+), ([^,]+), reqid (\d+), (.+) SPIs:.+`)
+	ssChildSAStatusRE    = regexp.MustCompile(`^([^,]+), ([^,]+), reqid (\d+), (.+) SPIs: ([0-9a-f]+)_i ([0-9a-f]+)_o`)
 	ssChildSATrafficRE   = regexp.MustCompile(`(\d+) bytes_i(?: \((\d+) pkts?[^)]*\))?, (\d+) bytes_o(?: \((\d+) pkts?[^)]*\))?`)
 	ssChildSATSRE        = regexp.MustCompile(`^ (.+) === (.+)$`)
 )
@@ -60,6 +99,14 @@ func (e *Exporter) scrapeStrongswan(b []byte) (m metrics, ok bool) {
 	for i, line := range lines {
 		switch {
 		case strings.HasPrefix(line, ssPrefixStatus):
+			m.Daemon.Implementation = "strongswan"
+			matches := ssStatusRE.FindStringSubmatch(line)
+			if matches != nil {
+				m.Daemon.Version = matches[1]
+				m.Daemon.SysName = matches[2]
+				m.Daemon.Release = matches[3]
+				m.Daemon.Machine = matches[4]
+			}
 			j := i
 			if i+1 < len(lines) {
 				j++
@@ -70,7 +117,22 @@ func (e *Exporter) scrapeStrongswan(b []byte) (m metrics, ok bool) {
 				}
 				matches := ssUptimeRE.FindStringSubmatch(line)
 				if matches != nil {
-					m.Stats.Uptime.Since = matches[1]
+					m.Stats.Uptime.Running = matches[1]
+					m.Stats.Uptime.Since = matches[2]
+					continue
+				}
+				matches = ssMallocRE.FindStringSubmatch(line)
+				if matches != nil {
+					m.Stats.Mallinfo = &mallinfo{}
+					m.Stats.Mallinfo.Sbrk, _ = strconv.ParseUint(matches[1], 10, 64)
+					m.Stats.Mallinfo.Mmap, _ = strconv.ParseUint(matches[2], 10, 64)
+					m.Stats.Mallinfo.Used, _ = strconv.ParseUint(matches[3], 10, 64)
+					m.Stats.Mallinfo.Free, _ = strconv.ParseUint(matches[4], 10, 64)
+					continue
+				}
+				matches = ssPluginsRE.FindStringSubmatch(line)
+				if matches != nil {
+					m.Stats.Plugins = strings.Fields(matches[1])
 					continue
 				}
 				matches = ssStatsRE.FindStringSubmatch(line)
@@ -91,6 +153,18 @@ func (e *Exporter) scrapeStrongswan(b []byte) (m metrics, ok bool) {
 					m.Stats.Scheduled = &n
 				}
 			}
+		case line == ssPrefixListen:
+			j := i
+			if i+1 < len(lines) {
+				j++
+			}
+			for _, line := range lines[j:] {
+				matches := ssListenRE.FindStringSubmatch(line)
+				if matches == nil {
+					break
+				}
+				m.Listening = append(m.Listening, listeningAddress{Address: matches[1]})
+			}
 		case line == ssPrefixPools:
 			j := i
 			if i+1 < len(lines) {
@@ -107,6 +181,98 @@ func (e *Exporter) scrapeStrongswan(b []byte) (m metrics, ok bool) {
 				pool.Offline, _ = strconv.ParseUint(matches[4], 10, 64)
 				m.Pools = append(m.Pools, pool)
 			}
+		case line == ssPrefixConns:
+			j := i
+			if i+1 < len(lines) {
+				j++
+			}
+			var conn *connection
+			for _, line := range lines[j:] {
+				if !ssConnPrefixRE.MatchString(line) {
+					break
+				}
+				matches := ssConnRE.FindStringSubmatch(line)
+				if matches != nil {
+					conn = &connection{
+						Name:       matches[1],
+						LocalHost:  matches[2],
+						RemoteHost: matches[3],
+					}
+					if matches[4] == "1/2" {
+						conn.Version = ikeVersionAny
+					} else if n, err := strconv.ParseUint(matches[4], 10, 8); err == nil {
+						conn.Version = uint8(n)
+					}
+					if matches[5] != "" {
+						n, _ := strconv.ParseUint(matches[5], 10, 64)
+						conn.DPDDelay = &n
+					}
+					m.Connections = append(m.Connections, conn)
+					continue
+				}
+				if conn == nil {
+					continue
+				}
+				matches = ssConnAuthRE.FindStringSubmatch(line)
+				if matches != nil {
+					// Only the first authentication round is reported
+					if matches[1] == "local" && conn.LocalAuth == "" {
+						conn.LocalID = matches[2]
+						conn.LocalAuth = matches[3]
+					} else if matches[1] == "remote" && conn.RemoteAuth == "" {
+						conn.RemoteID = matches[2]
+						conn.RemoteAuth = matches[3]
+					}
+					continue
+				}
+				matches = ssConnChildRE.FindStringSubmatch(line)
+				if matches != nil {
+					// Children are listed under the connection they belong to
+					conn.Children = append(conn.Children, &connectionChild{
+						Name:      matches[1],
+						Mode:      matches[4],
+						LocalTS:   strings.Split(matches[2], " "),
+						RemoteTS:  strings.Split(matches[3], " "),
+						DPDAction: matches[5],
+					})
+				}
+			}
+		case line == ssPrefixRouted:
+			j := i
+			if i+1 < len(lines) {
+				j++
+			}
+			for _, line := range lines[j:] {
+				matches := ssRoutedRE.FindStringSubmatch(line)
+				if matches != nil {
+					m.Policies = append(m.Policies, policy{Name: matches[1], Mode: matches[2]})
+					continue
+				}
+				matches = ssRoutedTSRE.FindStringSubmatch(line)
+				if matches == nil || len(m.Policies) == 0 {
+					break
+				}
+				p := &m.Policies[len(m.Policies)-1]
+				p.LocalTS = strings.Split(matches[1], " ")
+				p.RemoteTS = strings.Split(matches[2], " ")
+			}
+		case line == ssPrefixShunts:
+			j := i
+			if i+1 < len(lines) {
+				j++
+			}
+			for _, line := range lines[j:] {
+				matches := ssShuntRE.FindStringSubmatch(line)
+				if matches == nil {
+					break
+				}
+				m.Policies = append(m.Policies, policy{
+					Name:     matches[1],
+					Mode:     matches[4],
+					LocalTS:  strings.Split(matches[2], " "),
+					RemoteTS: strings.Split(matches[3], " "),
+				})
+			}
 		case strings.HasPrefix(line, ssPrefixSA):
 			matches := ssSAHeaderRE.FindStringSubmatch(line)
 			if matches != nil {
@@ -167,7 +333,7 @@ func (e *Exporter) scrapeStrongswan(b []byte) (m metrics, ok bool) {
 							sa.RemoteID = matches[5]
 							continue
 						}
-						matches = ssSAVersionRE.FindStringSubmatch(line)
+						matches = ssSASPIsRE.FindStringSubmatch(line)
 						if matches != nil {
 							switch matches[1] {
 							case "IKEv1":
@@ -175,6 +341,24 @@ func (e *Exporter) scrapeStrongswan(b []byte) (m metrics, ok bool) {
 							case "IKEv2":
 								sa.Version = 2
 							}
+							sa.InitiatorSPI = matches[2]
+							sa.ResponderSPI = matches[4]
+							// Our own SPI is marked with an asterisk
+							initiator := matches[3] != ""
+							sa.Initiator = &initiator
+							continue
+						}
+						matches = ssSATasksRE.FindStringSubmatch(line)
+						if matches != nil {
+							tasks := strings.Fields(matches[2])
+							switch matches[1] {
+							case "queued":
+								sa.TasksQueued = tasks
+							case "active":
+								sa.TasksActive = tasks
+							case "passive":
+								sa.TasksPassive = tasks
+							}
 							continue
 						}
 						matches = ssSARemoteIdentityRE.FindStringSubmatch(line)
@@ -195,6 +379,8 @@ func (e *Exporter) scrapeStrongswan(b []byte) (m metrics, ok bool) {
 							u := uint32(n)
 							childSA2.ReqID = &u
 							childSA2.Protocol = matches[4]
+							childSA2.SPIIn = matches[5]
+							childSA2.SPIOut = matches[6]
 							continue
 						}
 						matches = ssChildSATrafficRE.FindStringSubmatch(line)
@@ -232,4 +418,12 @@ func init() {
 	for k, v := range ssChildSAStates {
 		childSAStates[k] = v
 	}
+	ikeSAKnownStates["strongswan"] = ssIKESAStates
+	childSAKnownStates["strongswan"] = ssChildSAStates
+	for k, v := range ssIKESAStatuses {
+		ikeSAStatuses[k] = v
+	}
+	for k, v := range ssChildSAStatuses {
+		childSAStatuses[k] = v
+	}
 }
@@ -0,0 +1,222 @@
+package exporter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	swUptimeRE           = regexp.MustCompile(`^uptime: (.+), since (.+)$`)
+	swWorkersRE          = regexp.MustCompile(`^worker threads: (\d+) total, (\d+) idle, working: (\d+)/(\d+)/(\d+)/(\d+)$`)
+	swQueuesRE           = regexp.MustCompile(`^job queues: (\d+)/(\d+)/(\d+)/(\d+)$`)
+	swScheduledRE        = regexp.MustCompile(`^jobs scheduled: (\d+)$`)
+	swIKESAsRE           = regexp.MustCompile(`^IKE_SAs: (\d+) total, (\d+) half-open$`)
+	swMemRE              = regexp.MustCompile(`^memory usage: (\d+) bytes, (\d+) allocations$`)
+	swMallinfoRE         = regexp.MustCompile(`^mallinfo: sbrk (\d+), mmap (\d+), used (\d+), free (\d+)$`)
+	swPluginsRE          = regexp.MustCompile(`^loaded plugins:(.*)$`)
+	swPoolRE             = regexp.MustCompile(`^(\S+) +(\S+) +(\d+) / +(\d+) / (\d+)$`)
+	swSAHeaderRE         = regexp.MustCompile(`^([^ ].*): #(\d+), ([A-Z_]+), IKEv(\d), ([0-9a-f]+)_i(\*?) ([0-9a-f]+)_r\*?$`)
+	swSAEndpointRE       = regexp.MustCompile(`^  (local|remote) +'(.*)' @ (.+?)\[\d+](.*)$`)
+	swSAIdentityRE       = regexp.MustCompile(` (EAP|XAuth): '([^']*)'`)
+	swSAVIPRE            = regexp.MustCompile(` \[([^]]+)]`)
+	swSAEstablishedRE    = regexp.MustCompile(`^  established (\d+)s ago`)
+	swSATasksRE          = regexp.MustCompile(`^  (queued|active|passive): +(.+)$`)
+	swChildSAHeaderRE    = regexp.MustCompile(`^  ([^ ].*): #(\d+), reqid (\d+), ([A-Z_]+), ([A-Z]+)(?:-in-UDP)?, ([A-Z]+):`)
+	swChildSAInstalledRE = regexp.MustCompile(`^    installed (\d+)s ago`)
+	swChildSATrafficRE   = regexp.MustCompile(`^    (in|out) +([0-9a-f]+)[^,]*, +(\d+) bytes, +(\d+) packets`)
+	swChildSATSRE        = regexp.MustCompile(`^    (local|remote) +(.+)$`)
+)
+
+// scrapeSwanctl parses the output of swanctl --stats, --list-pools and
+// --list-sas commands, in any combination.
+func (e *Exporter) scrapeSwanctl(b []byte) (m metrics, ok bool) {
+	m.Daemon.Implementation = "strongswan"
+	var (
+		sa       *ikeSA
+		childSA2 *childSA
+		stats    bool
+	)
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if matches := swSAHeaderRE.FindStringSubmatch(line); matches != nil {
+			sa = &ikeSA{
+				Name:         matches[1],
+				State:        matches[3],
+				InitiatorSPI: matches[5],
+				ResponderSPI: matches[7],
+				ChildSAs:     make(map[string]*childSA),
+			}
+			n, _ := strconv.ParseUint(matches[2], 10, 32)
+			sa.UID = uint32(n)
+			n, _ = strconv.ParseUint(matches[4], 10, 8)
+			sa.Version = uint8(n)
+			// Our own SPI is marked with an asterisk
+			initiator := matches[6] != ""
+			sa.Initiator = &initiator
+			m.IKESAs = append(m.IKESAs, sa)
+			childSA2 = nil
+			continue
+		}
+		if !strings.HasPrefix(line, "  ") {
+			sa, childSA2 = nil, nil
+		}
+		switch {
+		case sa != nil && childSA2 != nil && strings.HasPrefix(line, "    "):
+			if matches := swChildSAInstalledRE.FindStringSubmatch(line); matches != nil {
+				n, _ := strconv.ParseInt(matches[1], 10, 64)
+				childSA2.Installed = &n
+				continue
+			}
+			if matches := swChildSATrafficRE.FindStringSubmatch(line); matches != nil {
+				bytes, _ := strconv.ParseUint(matches[3], 10, 64)
+				packets, _ := strconv.ParseUint(matches[4], 10, 64)
+				if matches[1] == "in" {
+					childSA2.SPIIn = matches[2]
+					childSA2.InBytes = bytes
+					childSA2.InPackets = &packets
+				} else {
+					childSA2.SPIOut = matches[2]
+					childSA2.OutBytes = bytes
+					childSA2.OutPackets = &packets
+				}
+				continue
+			}
+			if matches := swChildSATSRE.FindStringSubmatch(line); matches != nil {
+				if matches[1] == "local" {
+					childSA2.LocalTS = strings.Fields(matches[2])
+				} else {
+					childSA2.RemoteTS = strings.Fields(matches[2])
+				}
+			}
+		case sa != nil:
+			if matches := swChildSAHeaderRE.FindStringSubmatch(line); matches != nil {
+				childSA2 = &childSA{
+					Name:     matches[1],
+					State:    matches[4],
+					Mode:     matches[5],
+					Protocol: matches[6],
+				}
+				n, _ := strconv.ParseUint(matches[2], 10, 32)
+				childSA2.UID = uint32(n)
+				n, _ = strconv.ParseUint(matches[3], 10, 32)
+				reqID := uint32(n)
+				childSA2.ReqID = &reqID
+				sa.ChildSAs[fmt.Sprintf("%s-%d", childSA2.Name, childSA2.UID)] = childSA2
+				continue
+			}
+			childSA2 = nil
+			if matches := swSAEndpointRE.FindStringSubmatch(line); matches != nil {
+				var vips []string
+				for _, vip := range swSAVIPRE.FindAllStringSubmatch(matches[4], -1) {
+					vips = append(vips, vip[1])
+				}
+				if matches[1] == "local" {
+					sa.LocalID = matches[2]
+					sa.LocalHost = matches[3]
+					sa.LocalVIPs = vips
+					continue
+				}
+				sa.RemoteID = matches[2]
+				sa.RemoteHost = matches[3]
+				sa.RemoteVIPs = vips
+				for _, id := range swSAIdentityRE.FindAllStringSubmatch(matches[4], -1) {
+					if id[1] == "XAuth" {
+						sa.RemoteXAuthID = id[2]
+					} else {
+						sa.RemoteEAPID = id[2]
+					}
+				}
+				continue
+			}
+			if matches := swSAEstablishedRE.FindStringSubmatch(line); matches != nil {
+				n, _ := strconv.ParseInt(matches[1], 10, 64)
+				sa.Established = &n
+				continue
+			}
+			if matches := swSATasksRE.FindStringSubmatch(line); matches != nil {
+				tasks := strings.Fields(matches[2])
+				switch matches[1] {
+				case "queued":
+					sa.TasksQueued = tasks
+				case "active":
+					sa.TasksActive = tasks
+				case "passive":
+					sa.TasksPassive = tasks
+				}
+			}
+		default:
+			if matches := swUptimeRE.FindStringSubmatch(line); matches != nil {
+				m.Stats.Uptime.Running = matches[1]
+				m.Stats.Uptime.Since = matches[2]
+				continue
+			}
+			if matches := swWorkersRE.FindStringSubmatch(line); matches != nil {
+				m.Stats.Workers = &workers{}
+				m.Stats.Workers.Total, _ = strconv.ParseUint(matches[1], 10, 64)
+				m.Stats.Workers.Idle, _ = strconv.ParseUint(matches[2], 10, 64)
+				m.Stats.Workers.Active.Critical, _ = strconv.ParseUint(matches[3], 10, 64)
+				m.Stats.Workers.Active.High, _ = strconv.ParseUint(matches[4], 10, 64)
+				m.Stats.Workers.Active.Medium, _ = strconv.ParseUint(matches[5], 10, 64)
+				m.Stats.Workers.Active.Low, _ = strconv.ParseUint(matches[6], 10, 64)
+				continue
+			}
+			if matches := swQueuesRE.FindStringSubmatch(line); matches != nil {
+				m.Stats.Queues = &queues{}
+				m.Stats.Queues.Critical, _ = strconv.ParseUint(matches[1], 10, 64)
+				m.Stats.Queues.High, _ = strconv.ParseUint(matches[2], 10, 64)
+				m.Stats.Queues.Medium, _ = strconv.ParseUint(matches[3], 10, 64)
+				m.Stats.Queues.Low, _ = strconv.ParseUint(matches[4], 10, 64)
+				continue
+			}
+			if matches := swScheduledRE.FindStringSubmatch(line); matches != nil {
+				n, _ := strconv.ParseUint(matches[1], 10, 64)
+				m.Stats.Scheduled = &n
+				continue
+			}
+			if matches := swIKESAsRE.FindStringSubmatch(line); matches != nil {
+				m.Stats.IKESAs.Total, _ = strconv.ParseUint(matches[1], 10, 64)
+				m.Stats.IKESAs.HalfOpen, _ = strconv.ParseUint(matches[2], 10, 64)
+				stats = true
+				continue
+			}
+			if matches := swMemRE.FindStringSubmatch(line); matches != nil {
+				m.Stats.Mem = &mem{}
+				m.Stats.Mem.Total, _ = strconv.ParseUint(matches[1], 10, 64)
+				m.Stats.Mem.Allocs, _ = strconv.ParseUint(matches[2], 10, 64)
+				continue
+			}
+			if matches := swMallinfoRE.FindStringSubmatch(line); matches != nil {
+				m.Stats.Mallinfo = &mallinfo{}
+				m.Stats.Mallinfo.Sbrk, _ = strconv.ParseUint(matches[1], 10, 64)
+				m.Stats.Mallinfo.Mmap, _ = strconv.ParseUint(matches[2], 10, 64)
+				m.Stats.Mallinfo.Used, _ = strconv.ParseUint(matches[3], 10, 64)
+				m.Stats.Mallinfo.Free, _ = strconv.ParseUint(matches[4], 10, 64)
+				continue
+			}
+			if matches := swPluginsRE.FindStringSubmatch(line); matches != nil {
+				m.Stats.Plugins = strings.Fields(matches[1])
+				continue
+			}
+			if matches := swPoolRE.FindStringSubmatch(line); matches != nil {
+				pool := pool{Name: matches[1], Address: matches[2]}
+				pool.Online, _ = strconv.ParseUint(matches[3], 10, 64)
+				pool.Offline, _ = strconv.ParseUint(matches[4], 10, 64)
+				pool.Size, _ = strconv.ParseUint(matches[5], 10, 64)
+				m.Pools = append(m.Pools, pool)
+			}
+		}
+	}
+	if !stats {
+		// Without --stats counting the listed IKE SAs
+		m.Stats.IKESAs.Total = uint64(len(m.IKESAs))
+		for _, sa := range m.IKESAs {
+			if sa.State == "CONNECTING" {
+				m.Stats.IKESAs.HalfOpen++
+			}
+		}
+	}
+	ok = true
+	return
+}
@@ -0,0 +1,119 @@
+package exporter
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestExporter_scrapeSwanctl(t *testing.T) {
+	files, err := filepath.Glob("testdata/swanctl/*-command.txt")
+	if err != nil {
+		panic("failed to list test files: " + err.Error())
+	}
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			in, err := ioutil.ReadFile(file)
+			if err != nil {
+				panic("failed to read " + file + ": " + err.Error())
+			}
+			exporter, err := New(CollectorIpsec, nil, 0, nil, log.NewNopLogger())
+			if err != nil {
+				t.Fatalf("New() = _, %v; want nil", err)
+			}
+			exporter.scrape = func(e *Exporter) (m metrics, ok bool) { return e.scrapeSwanctl(in) }
+			outFile := strings.Replace(file, "-command.txt", "-metrics.txt", 1)
+			if _, err := os.Stat(outFile); err == nil {
+				out, err := ioutil.ReadFile(outFile)
+				if err != nil {
+					panic("failed to read " + outFile + ": " + err.Error())
+				}
+				if err = testutil.CollectAndCompare(exporter, bytes.NewReader(out)); err != nil {
+					t.Errorf("testutil.CollectAndCompare() = %v; want nil", err)
+				}
+			} else {
+				if err = ioutil.WriteFile(outFile, collect(t, exporter), 0666); err != nil {
+					panic("failed to write " + outFile + ": " + err.Error())
+				}
+				t.Logf("wrote %s golden master", outFile)
+			}
+		})
+	}
+}
+
+func TestReSWMarker(t *testing.T) {
+	for _, file := range []string{"testdata/swanctl/1-command.txt", "testdata/swanctl/2-command.txt"} {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			panic("failed to read " + file + ": " + err.Error())
+		}
+		if reSSMarker.Match(b) || reLSMarker.Match(b) || !reSWMarker.Match(b) {
+			t.Errorf("%s is not detected as swanctl output", file)
+		}
+	}
+	b, err := ioutil.ReadFile("testdata/strongswan/1-command.txt")
+	if err != nil {
+		panic("failed to read testdata/strongswan/1-command.txt: " + err.Error())
+	}
+	if reSWMarker.Match(b) {
+		t.Error("testdata/strongswan/1-command.txt is detected as swanctl output")
+	}
+}
+
+func TestExporter_scrapeIpsec_EmptySwanctl(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping TestExporter_scrapeIpsec_EmptySwanctl on windows")
+	}
+	dir, err := ioutil.TempDir("", "ipsec_exporter")
+	if err != nil {
+		panic("failed to create temp dir: " + err.Error())
+	}
+	defer os.RemoveAll(dir)
+	swanctl := filepath.Join(dir, "swanctl")
+	if err = ioutil.WriteFile(swanctl, []byte("#!/bin/sh\n"), 0777); err != nil {
+		panic("failed to write " + swanctl + ": " + err.Error())
+	}
+	tests := []struct {
+		cmd  []string
+		want string
+	}{
+		{
+			cmd: []string{swanctl, "--list-sas"},
+			want: `# HELP ipsec_half_open_ike_sas Number of IKE SAs in half-open state.
+# TYPE ipsec_half_open_ike_sas gauge
+ipsec_half_open_ike_sas 0
+# HELP ipsec_ike_sas Number of currently registered IKE SAs.
+# TYPE ipsec_ike_sas gauge
+ipsec_ike_sas 0
+# HELP ipsec_up Was the last scrape successful.
+# TYPE ipsec_up gauge
+ipsec_up 1
+`,
+		},
+		{
+			cmd: []string{"/bin/sh", "-c", "true"},
+			want: `# HELP ipsec_up Was the last scrape successful.
+# TYPE ipsec_up gauge
+ipsec_up 0
+`,
+		},
+	}
+	for _, td := range tests {
+		t.Run(strings.Join(td.cmd, " "), func(t *testing.T) {
+			exporter, err := New(CollectorIpsec, nil, 0, td.cmd, log.NewNopLogger())
+			if err != nil {
+				t.Fatalf("New() = _, %v; want nil", err)
+			}
+			if err = testutil.CollectAndCompare(exporter, strings.NewReader(td.want), "ipsec_up", "ipsec_ike_sas", "ipsec_half_open_ike_sas"); err != nil {
+				t.Errorf("testutil.CollectAndCompare() = %v; want nil", err)
+			}
+		})
+	}
+}
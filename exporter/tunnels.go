@@ -0,0 +1,84 @@
+package exporter
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Tunnel is a tunnel expected to be up.
+type Tunnel struct {
+	// Name is the tunnel label value, the connection name by default.
+	Name string `yaml:"name"`
+	// Connection is the name of the connection the tunnel belongs to.
+	Connection string `yaml:"connection"`
+	// Child is the name of the child SAs to count, any child SAs are
+	// counted by default.
+	Child string `yaml:"child"`
+	// RemoteHost is the IKE SA remote host, any host by default.
+	RemoteHost string `yaml:"remote_host"`
+	// MinChildSAs is the number of installed child SAs needed for the tunnel
+	// to be up, 1 by default.
+	MinChildSAs int `yaml:"min_child_sas"`
+}
+
+type tunnelsConfig struct {
+	Tunnels []Tunnel `yaml:"tunnels"`
+}
+
+// LoadTunnels reads expected tunnels from the YAML file.
+func LoadTunnels(path string) ([]Tunnel, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg tunnelsConfig
+	if err := yaml.UnmarshalStrict(b, &cfg); err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool)
+	for i := range cfg.Tunnels {
+		t := &cfg.Tunnels[i]
+		if t.Connection == "" {
+			return nil, fmt.Errorf("tunnel #%d: connection is required", i+1)
+		}
+		if t.Name == "" {
+			t.Name = t.Connection
+		}
+		if names[t.Name] {
+			return nil, fmt.Errorf("tunnel #%d: duplicate name %q", i+1, t.Name)
+		}
+		names[t.Name] = true
+		if t.MinChildSAs < 0 {
+			return nil, fmt.Errorf("tunnel %q: min_child_sas must not be negative", t.Name)
+		}
+		if t.MinChildSAs == 0 {
+			t.MinChildSAs = 1
+		}
+	}
+	return cfg.Tunnels, nil
+}
+
+// up reports whether the tunnel has enough installed child SAs
+// in established IKE SAs.
+func (t Tunnel) up(ikeSAs []*ikeSA) bool {
+	n := 0
+	for _, ikeSA := range ikeSAs {
+		if ikeSA.ConnectionName() != t.Connection || (t.RemoteHost != "" && ikeSA.RemoteHost != t.RemoteHost) {
+			continue
+		}
+		if status, ok := ikeSAStatuses[ikeSA.State]; !ok || !isUp(status) {
+			continue
+		}
+		for _, childSA := range ikeSA.ChildSAs {
+			if t.Child != "" && childSA.Name != t.Child {
+				continue
+			}
+			if status, ok := childSAStatuses[childSA.State]; ok && isUp(status) {
+				n++
+			}
+		}
+	}
+	return n >= t.MinChildSAs
+}
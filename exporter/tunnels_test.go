@@ -0,0 +1,92 @@
+package exporter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestLoadTunnels(t *testing.T) {
+	tunnels, err := LoadTunnels("testdata/tunnels/tunnels.yml")
+	if err != nil {
+		t.Fatalf("LoadTunnels() = _, %v; want nil", err)
+	}
+	want := []Tunnel{
+		{Name: "named", Connection: "named", MinChildSAs: 1},
+		{Name: "named-child", Connection: "named", Child: "named", RemoteHost: "10.0.3.1", MinChildSAs: 2},
+	}
+	if !reflect.DeepEqual(tunnels, want) {
+		t.Errorf("LoadTunnels() = %v, _; want %v", tunnels, want)
+	}
+	for _, name := range []string{"no-connection", "duplicate"} {
+		if _, err := LoadTunnels("testdata/tunnels/" + name + ".yml"); err == nil {
+			t.Errorf("LoadTunnels(%q) = _, nil; want not nil", name)
+		}
+	}
+}
+
+func TestExporter_Collect_ExpectedTunnels(t *testing.T) {
+	tunnels := []Tunnel{
+		{Name: "named", Connection: "named", MinChildSAs: 1},
+		{Name: "named-child", Connection: "named", Child: "named", MinChildSAs: 2},
+		{Name: "named-remote", Connection: "named", RemoteHost: "10.0.3.2", MinChildSAs: 1},
+		{Name: "named-connecting", Connection: "named-connecting", MinChildSAs: 1},
+		{Name: "missing", Connection: "missing", MinChildSAs: 1},
+	}
+	exporter, err := New(CollectorIpsec, nil, 0, nil, log.NewNopLogger(), WithExpectedTunnels(tunnels))
+	if err != nil {
+		t.Fatalf("New() = _, %v; want nil", err)
+	}
+	exporter.scrape = func(e *Exporter) (m metrics, ok bool) {
+		return metrics{
+			IKESAs: []*ikeSA{
+				{
+					Name:       "named[1]",
+					State:      "ESTABLISHED",
+					RemoteHost: "10.0.3.1",
+					ChildSAs: map[string]*childSA{
+						"named-1": {Name: "named", State: "INSTALLED"},
+						"other-2": {Name: "other", State: "INSTALLED"},
+					},
+				},
+				{
+					Name:       "named-connecting",
+					State:      "CONNECTING",
+					RemoteHost: "10.0.3.3",
+					ChildSAs: map[string]*childSA{
+						"named-3": {Name: "named", State: "INSTALLED"},
+					},
+				},
+			},
+		}, true
+	}
+	expected := `
+# HELP ipsec_expected_tunnel_up Whether the expected tunnel has enough installed child SAs.
+# TYPE ipsec_expected_tunnel_up gauge
+ipsec_expected_tunnel_up{tunnel="missing"} 0
+ipsec_expected_tunnel_up{tunnel="named"} 1
+ipsec_expected_tunnel_up{tunnel="named-child"} 0
+ipsec_expected_tunnel_up{tunnel="named-connecting"} 0
+ipsec_expected_tunnel_up{tunnel="named-remote"} 0
+`
+	if err := testutil.CollectAndCompare(exporter, strings.NewReader(expected), "ipsec_expected_tunnel_up"); err != nil {
+		t.Errorf("testutil.CollectAndCompare() = %v; want nil", err)
+	}
+
+	exporter.scrape = func(e *Exporter) (m metrics, ok bool) { return }
+	expected = `
+# HELP ipsec_expected_tunnel_up Whether the expected tunnel has enough installed child SAs.
+# TYPE ipsec_expected_tunnel_up gauge
+ipsec_expected_tunnel_up{tunnel="missing"} 0
+ipsec_expected_tunnel_up{tunnel="named"} 0
+ipsec_expected_tunnel_up{tunnel="named-child"} 0
+ipsec_expected_tunnel_up{tunnel="named-connecting"} 0
+ipsec_expected_tunnel_up{tunnel="named-remote"} 0
+`
+	if err := testutil.CollectAndCompare(exporter, strings.NewReader(expected), "ipsec_expected_tunnel_up"); err != nil {
+		t.Errorf("testutil.CollectAndCompare() = %v; want nil", err)
+	}
+}
@@ -0,0 +1,173 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+)
+
+const uptimeSinceLayout = "Jan _2 15:04:05 2006"
+
+// uptimeSlack is a tolerance for the start time computed from the absolute
+// time to differ from the relative one due to delays between the daemon
+// formatting the stats and the exporter processing them.
+const uptimeSlack = time.Minute
+
+// userHZ is the number of clock ticks per second used by /proc/<pid>/stat.
+// It's not exposed by procfs but the kernel fixes it at 100 for userspace
+// on all the Linux architectures, see WithProcFS.
+const userHZ = 100
+
+var uptimeRunningRE = regexp.MustCompile(`^(\d+) (second|minute|hour|day)s?$`)
+
+var uptimeRunningUnits = map[string]time.Duration{
+	"second": time.Second,
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+}
+
+// startTime returns the daemon start time and its precision. The absolute
+// start time is preferred as it's precise but it's parsed in the configured
+// location and is checked against the relative running time which is rounded
+// down to the unit it's reported in but doesn't depend on the daemon timezone.
+func (e *Exporter) startTime(u uptime) (t time.Time, precision time.Duration, ok bool) {
+	if !u.Started.IsZero() {
+		return u.Started, time.Second, true
+	}
+	current := now().Round(time.Second)
+	var (
+		running, unit time.Duration
+		err           error
+	)
+	if u.Running != "" {
+		if running, unit, err = parseRunning(u.Running); err != nil {
+			level.Warn(e.logger).Log("msg", "Failed to parse uptime", "running", u.Running, "err", err)
+		}
+	}
+	if u.Since != "" {
+		since, err := time.ParseInLocation(uptimeSinceLayout, u.Since, e.location)
+		switch {
+		case err != nil:
+			level.Warn(e.logger).Log("msg", "Failed to parse uptime", "since", u.Since, "err", err)
+		case unit == 0:
+			return since, time.Second, true
+		default:
+			d := current.Sub(since)
+			if d >= running-uptimeSlack && d < running+unit+uptimeSlack {
+				return since, time.Second, true
+			}
+			level.Debug(e.logger).Log("msg", "Uptime does not match the running time, check the timezone", "since", u.Since, "running", u.Running, "location", e.location)
+		}
+	}
+	if unit != 0 {
+		return current.Add(-running), unit, true
+	}
+	return
+}
+
+func parseRunning(s string) (d, unit time.Duration, err error) {
+	matches := uptimeRunningRE.FindStringSubmatch(s)
+	if matches == nil {
+		return 0, 0, fmt.Errorf("unknown running time format %q", s)
+	}
+	n, err := strconv.ParseInt(matches[1], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	unit = uptimeRunningUnits[matches[2]]
+	return time.Duration(n) * unit, unit, nil
+}
+
+// plutoStartTime returns the pluto process start time using its pid file
+// and procfs.
+func (e *Exporter) plutoStartTime() (time.Time, error) {
+	b, err := ioutil.ReadFile(e.pidFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+	pid, err := strconv.Atoi(string(bytes.TrimSpace(b)))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid pid file %s: %w", e.pidFile, err)
+	}
+	file := filepath.Join(e.procFS, strconv.Itoa(pid), "stat")
+	if b, err = ioutil.ReadFile(file); err != nil {
+		return time.Time{}, err
+	}
+	// The process name may contain spaces and parentheses so the fields
+	// are counted from the last closing parenthesis.
+	i := bytes.IndexByte(b, '(')
+	j := bytes.LastIndexByte(b, ')')
+	if i < 0 || j < i {
+		return time.Time{}, fmt.Errorf("invalid %s", file)
+	}
+	if comm := string(b[i+1 : j]); comm != "pluto" {
+		return time.Time{}, fmt.Errorf("process %d is %s, not pluto", pid, comm)
+	}
+	fields := strings.Fields(string(b[j+1:]))
+	// starttime is the 22nd field, the 20th after the process name.
+	if len(fields) < 20 {
+		return time.Time{}, fmt.Errorf("invalid %s", file)
+	}
+	ticks, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: %w", file, err)
+	}
+	bootTime, err := e.bootTime()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return bootTime.Add(time.Duration(ticks) * time.Second / userHZ).Round(time.Second), nil
+}
+
+func (e *Exporter) bootTime() (time.Time, error) {
+	file := filepath.Join(e.procFS, "stat")
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		if !strings.HasPrefix(line, "btime ") {
+			continue
+		}
+		sec, err := strconv.ParseInt(strings.TrimSpace(line[len("btime "):]), 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid %s: %w", file, err)
+		}
+		return time.Unix(sec, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("no btime in %s", file)
+}
+
+// restartTracker counts daemon restarts by watching its start time
+// moving forward by more than it can be measured with.
+type restartTracker struct {
+	mu        sync.Mutex
+	started   time.Time
+	precision time.Duration
+	restarts  uint64
+}
+
+func (r *restartTracker) observe(started time.Time, precision time.Duration) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.started.IsZero() {
+		tolerance := precision
+		if r.precision > tolerance {
+			tolerance = r.precision
+		}
+		if started.Sub(r.started) > tolerance {
+			r.restarts++
+		}
+	}
+	r.started, r.precision = started, precision
+	return r.restarts
+}
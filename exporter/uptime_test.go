@@ -0,0 +1,158 @@
+package exporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+func TestExporter_startTime(t *testing.T) {
+	current := now().Round(time.Second)
+	since := func(d time.Duration, loc *time.Location) string {
+		return current.Add(-d).In(loc).Format(uptimeSinceLayout)
+	}
+	tests := []struct {
+		name      string
+		uptime    uptime
+		want      time.Duration
+		precision time.Duration
+		ok        bool
+	}{
+		{
+			name:   "empty",
+			uptime: uptime{},
+		},
+		{
+			name:      "since",
+			uptime:    uptime{Since: since(90*time.Second, time.UTC)},
+			want:      90 * time.Second,
+			precision: time.Second,
+			ok:        true,
+		},
+		{
+			name:      "since matching running",
+			uptime:    uptime{Running: "1 minute", Since: since(90*time.Second, time.UTC)},
+			want:      90 * time.Second,
+			precision: time.Second,
+			ok:        true,
+		},
+		{
+			name:      "since in another timezone",
+			uptime:    uptime{Running: "2 days", Since: since(50*time.Hour, time.FixedZone("UTC+3", 3*60*60))},
+			want:      48 * time.Hour,
+			precision: 24 * time.Hour,
+			ok:        true,
+		},
+		{
+			name:      "since in another locale",
+			uptime:    uptime{Running: "37 seconds", Since: "мар 14 10:28:49 2018"},
+			want:      37 * time.Second,
+			precision: time.Second,
+			ok:        true,
+		},
+		{
+			name:      "running",
+			uptime:    uptime{Running: "1 hour"},
+			want:      time.Hour,
+			precision: time.Hour,
+			ok:        true,
+		},
+		{
+			name:      "started",
+			uptime:    uptime{Running: "1 hour", Started: current.Add(-5 * time.Second)},
+			want:      5 * time.Second,
+			precision: time.Second,
+			ok:        true,
+		},
+		{
+			name:   "invalid",
+			uptime: uptime{Running: "forever", Since: "yesterday"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			exporter, err := New(CollectorIpsec, nil, 0, nil, log.NewNopLogger(), WithLocation(time.UTC))
+			if err != nil {
+				t.Fatalf("New() = _, %v; want nil", err)
+			}
+			started, precision, ok := exporter.startTime(test.uptime)
+			if ok != test.ok {
+				t.Fatalf("startTime(%+v) = _, _, %v; want %v", test.uptime, ok, test.ok)
+			}
+			if !ok {
+				return
+			}
+			if got := current.Sub(started); got != test.want {
+				t.Errorf("startTime(%+v) = %v ago, _, _; want %v ago", test.uptime, got, test.want)
+			}
+			if precision != test.precision {
+				t.Errorf("startTime(%+v) = _, %v, _; want %v", test.uptime, precision, test.precision)
+			}
+		})
+	}
+}
+
+func TestExporter_plutoStartTime(t *testing.T) {
+	tests := []struct {
+		pidFile string
+		want    time.Time
+		err     bool
+	}{
+		{
+			pidFile: "testdata/proc/pluto.pid",
+			want:    time.Unix(1636802014+12345, 0),
+		},
+		{
+			pidFile: "testdata/proc/charon.pid",
+			err:     true,
+		},
+		{
+			pidFile: "testdata/proc/missing.pid",
+			err:     true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.pidFile, func(t *testing.T) {
+			exporter, err := New(CollectorIpsec, nil, 0, nil, log.NewNopLogger(), WithPlutoPIDFile(test.pidFile), WithProcFS("testdata/proc"))
+			if err != nil {
+				t.Fatalf("New() = _, %v; want nil", err)
+			}
+			got, err := exporter.plutoStartTime()
+			if test.err {
+				if err == nil {
+					t.Errorf("plutoStartTime() = _, nil; want non-nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("plutoStartTime() = _, %v; want nil", err)
+			}
+			if !got.Equal(test.want) {
+				t.Errorf("plutoStartTime() = %v, _; want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRestartTracker_observe(t *testing.T) {
+	started := time.Unix(1636802014, 0)
+	observations := []struct {
+		started   time.Time
+		precision time.Duration
+		want      uint64
+	}{
+		{started: started, precision: time.Second, want: 0},
+		{started: started, precision: time.Second, want: 0},
+		{started: started.Add(59 * time.Second), precision: time.Minute, want: 0},
+		{started: started.Add(10 * time.Minute), precision: time.Minute, want: 1},
+		{started: started.Add(10*time.Minute - time.Second), precision: time.Second, want: 1},
+		{started: started.Add(time.Hour), precision: time.Second, want: 2},
+	}
+	var r restartTracker
+	for i, o := range observations {
+		if got := r.observe(o.started, o.precision); got != o.want {
+			t.Errorf("#%d: observe(%v, %v) = %d; want %d", i, o.started, o.precision, got, o.want)
+		}
+	}
+}
@@ -21,13 +21,15 @@ func (e *Exporter) scrapeVICI() (m metrics, ok bool) {
 	}
 	defer sess.Close()
 
+	m.Daemon = e.scrapeVICIVersion(sess)
+
 	msg, err := sess.CommandRequest("stats", nil)
 	if err != nil {
 		level.Error(e.logger).Log("msg", "Failed to send command", "cmd", "stats", "err", err)
 		return
 	}
 	if msg.Err() != nil {
-		level.Error(e.logger).Log("msg", "Failed to process command response", "cmd", "stats", "err", err)
+		level.Error(e.logger).Log("msg", "Failed to process command response", "cmd", "stats", "err", msg.Err())
 		return
 	}
 	if err = vici.UnmarshalMessage(msg, &m.Stats); err != nil {
@@ -41,7 +43,7 @@ func (e *Exporter) scrapeVICI() (m metrics, ok bool) {
 		return
 	}
 	if msg.Err() != nil {
-		level.Error(e.logger).Log("msg", "Failed to process command response", "cmd", "get-pools", "err", err)
+		level.Error(e.logger).Log("msg", "Failed to process command response", "cmd", "get-pools", "err", msg.Err())
 		return
 	}
 	pools := make(map[string]pool)
@@ -54,6 +56,8 @@ func (e *Exporter) scrapeVICI() (m metrics, ok bool) {
 		m.Pools = append(m.Pools, pool)
 	}
 
+	m.Policies = e.scrapeVICIPolicies(sess)
+
 	stream, err := sess.StreamedCommandRequest("list-sas", "list-sa", nil)
 	if err != nil {
 		level.Error(e.logger).Log("msg", "Failed to send command", "cmd", "list-sas", "err", err)
@@ -61,7 +65,7 @@ func (e *Exporter) scrapeVICI() (m metrics, ok bool) {
 	}
 	for _, msg := range stream.Messages() {
 		if msg.Err() != nil {
-			level.Error(e.logger).Log("msg", "Failed to process command response", "cmd", "list-sas", "err", err)
+			level.Error(e.logger).Log("msg", "Failed to process command response", "cmd", "list-sas", "err", msg.Err())
 			return
 		}
 		ikeSAs := make(map[string]ikeSA)
@@ -71,9 +75,66 @@ func (e *Exporter) scrapeVICI() (m metrics, ok bool) {
 		}
 		for name, ikeSA := range ikeSAs {
 			ikeSA.Name = name
+			if ikeSA.Initiator == nil {
+				// charon only sets the flag for IKE SAs we have initiated
+				ikeSA.Initiator = new(bool)
+			}
 			m.IKESAs = append(m.IKESAs, &ikeSA)
 		}
 	}
 	ok = true
 	return
 }
+
+// scrapeVICIVersion returns the daemon information. The version is only
+// informational so a failure to get it doesn't fail the scrape.
+func (e *Exporter) scrapeVICIVersion(sess *vici.Session) daemon {
+	d := daemon{Implementation: "strongswan"}
+	msg, err := sess.CommandRequest("version", nil)
+	if err != nil {
+		level.Warn(e.logger).Log("msg", "Failed to send command", "cmd", "version", "err", err)
+		return d
+	}
+	if msg.Err() != nil {
+		level.Warn(e.logger).Log("msg", "Failed to process command response", "cmd", "version", "err", msg.Err())
+		return d
+	}
+	if err = vici.UnmarshalMessage(msg, &d); err != nil {
+		level.Warn(e.logger).Log("msg", "Failed to unmarshal command response", "cmd", "version", "err", err)
+		return daemon{Implementation: "strongswan"}
+	}
+	return d
+}
+
+// scrapeVICIPolicies returns the installed trap and shunt policies. They're
+// optional like the libreswan supplementary commands, so a failure to list
+// them is logged and no policies are returned.
+func (e *Exporter) scrapeVICIPolicies(sess *vici.Session) (policies []policy) {
+	req := vici.NewMessage()
+	for _, typ := range []string{"trap", "drop", "pass"} {
+		if err := req.Set(typ, "yes"); err != nil {
+			level.Warn(e.logger).Log("msg", "Failed to create command request", "cmd", "list-policies", "err", err)
+			return nil
+		}
+	}
+	stream, err := sess.StreamedCommandRequest("list-policies", "list-policy", req)
+	if err != nil {
+		level.Warn(e.logger).Log("msg", "Failed to send command", "cmd", "list-policies", "err", err)
+		return nil
+	}
+	for _, msg := range stream.Messages() {
+		if msg.Err() != nil {
+			level.Warn(e.logger).Log("msg", "Failed to process command response", "cmd", "list-policies", "err", msg.Err())
+			return nil
+		}
+		m := make(map[string]policy)
+		if err = vici.UnmarshalMessage(msg, m); err != nil {
+			level.Warn(e.logger).Log("msg", "Failed to unmarshal command response", "cmd", "list-policies", "err", err)
+			return nil
+		}
+		for _, policy := range m {
+			policies = append(policies, policy)
+		}
+	}
+	return
+}
@@ -0,0 +1,99 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"time"
+	"unsafe"
+
+	"github.com/go-kit/kit/log/level"
+)
+
+// The status request is a "basic" whack message: the head of
+// struct whack_message from libreswan include/whack.h, as of libreswan 3.x
+// and 4.x (e.g. 4.3 in Debian bullseye), up to the "END OF BASIC COMMANDS"
+// marker:
+//
+//	struct whack_message {
+//		unsigned int magic;
+//		bool whack_status;
+//		...
+//		bool whack_shutdown;
+//		/* END OF BASIC COMMANDS */
+//		...
+//	};
+//
+// pluto accepts basic messages from any whack version as long as
+// the magic matches: libreswan changes WHACK_BASIC_MAGIC whenever this part
+// of the struct changes, and pluto replies with whackBadMagic to messages
+// with an unknown magic.
+
+// whackBasicMagic is WHACK_BASIC_MAGIC from libreswan include/whack.h.
+const whackBasicMagic = (((('w'<<8)+'h')<<8)+'k')<<8 + 25
+
+// whackBasicSize covers the basic commands, the flags after whack_status
+// are left zeroed.
+const whackBasicSize = 64
+
+// whackStatusOffset is the offset of whack_status, right after the
+// 4 byte magic.
+const whackStatusOffset = 4
+
+// whackBadMagic is a part of the pluto reply to a message with an unknown
+// magic.
+var whackBadMagic = []byte("ignoring message from whack with bad magic")
+
+// nativeEndian is the byte order the daemons use for their control
+// socket messages.
+var nativeEndian binary.ByteOrder = binary.LittleEndian
+
+func init() {
+	i := uint16(1)
+	if *(*byte)(unsafe.Pointer(&i)) == 0 {
+		nativeEndian = binary.BigEndian
+	}
+}
+
+func (e *Exporter) scrapeWhack() (m metrics, ok bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	conn, err := net.DialTimeout("unix", e.whackSocket, e.timeout)
+	if err != nil {
+		level.Error(e.logger).Log("msg", "Failed to connect to pluto", "err", err)
+		return
+	}
+	defer conn.Close()
+	if e.timeout > 0 {
+		conn.SetDeadline(time.Now().Add(e.timeout))
+	}
+
+	msg := make([]byte, whackBasicSize)
+	nativeEndian.PutUint32(msg, whackBasicMagic)
+	msg[whackStatusOffset] = 1
+	if _, err = conn.Write(msg); err != nil {
+		level.Error(e.logger).Log("msg", "Failed to send command", "cmd", "status", "err", err)
+		return
+	}
+	output, err := ioutil.ReadAll(conn)
+	if err != nil {
+		level.Error(e.logger).Log("msg", "Failed to read command response", "cmd", "status", "err", err)
+		return
+	}
+	if bytes.Contains(output, whackBadMagic) {
+		level.Error(e.logger).Log("msg", "Pluto rejected the whack message magic, its libreswan version is unsupported", "magic", whackBasicMagic, "output", output)
+		return
+	}
+	if !reLSMarker.Match(output) {
+		level.Error(e.logger).Log("msg", "Failed to recognize command response", "cmd", "status", "output", output)
+		return
+	}
+	if m, ok = e.scrapeLibreswan(output); ok {
+		if m.Stats.Uptime.Started, err = e.plutoStartTime(); err != nil {
+			level.Warn(e.logger).Log("msg", "Failed to get pluto start time", "err", err)
+		}
+		e.scrapeLibreswanOptional(&m)
+	}
+	return
+}
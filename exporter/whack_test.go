@@ -0,0 +1,159 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// whackStatusMsg is the status request sent by whack of libreswan 4.3 on
+// little-endian hosts: WHACK_BASIC_MAGIC followed by whack_status set
+// and the rest of the basic commands unset.
+var whackStatusMsg = append([]byte{0x19, 0x6b, 0x68, 0x77, 0x01}, make([]byte, 59)...)
+
+// fakePluto serves a single whack connection the way pluto does: it reads
+// one message and replies with out if the message has basicMagic
+// and whack_status set, or with the bad magic complaint.
+func fakePluto(socket string, basicMagic uint32, out []byte) (msgs chan []byte, stop func()) {
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		panic("failed to listen on " + socket + ": " + err.Error())
+	}
+	msgs = make(chan []byte, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		msg := make([]byte, 4096)
+		n, err := conn.Read(msg)
+		if err != nil {
+			return
+		}
+		msg = msg[:n]
+		msgs <- msg
+		if n < 5 || nativeEndian.Uint32(msg) != basicMagic {
+			var magic uint32
+			if n >= 4 {
+				magic = nativeEndian.Uint32(msg)
+			}
+			fmt.Fprintf(conn, "ignoring message from whack with bad magic %d; should be %d; Mismatched versions of userland tools.\n", magic, basicMagic-1)
+			return
+		}
+		if msg[4] == 1 {
+			conn.Write(out)
+		}
+	}()
+	return msgs, func() { l.Close() }
+}
+
+func TestExporter_scrapeWhack(t *testing.T) {
+	in, err := ioutil.ReadFile("testdata/libreswan/3-command.txt")
+	if err != nil {
+		panic("failed to read testdata/libreswan/3-command.txt: " + err.Error())
+	}
+	out, err := ioutil.ReadFile("testdata/libreswan/3-metrics.txt")
+	if err != nil {
+		panic("failed to read testdata/libreswan/3-metrics.txt: " + err.Error())
+	}
+	dir, err := ioutil.TempDir("", "ipsec_exporter")
+	if err != nil {
+		panic("failed to create temp dir: " + err.Error())
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "pluto.ctl")
+	msgs, stop := fakePluto(socket, 0x77686b19, in)
+	defer stop()
+
+	exporter, err := New(CollectorWhack, nil, 0, nil, log.NewNopLogger(), WithWhackSocket(socket), WithPlutoPIDFile(filepath.Join(dir, "pluto.pid")))
+	if err != nil {
+		t.Fatalf("New() = _, %v; want nil", err)
+	}
+	if err = testutil.CollectAndCompare(exporter, bytes.NewReader(out)); err != nil {
+		t.Errorf("testutil.CollectAndCompare() = %v; want nil", err)
+	}
+	msg := <-msgs
+	if nativeEndian != binary.LittleEndian {
+		t.Skip("skipping whack message comparison on big-endian")
+	}
+	if !bytes.Equal(msg, whackStatusMsg) {
+		t.Errorf("whack message = % x; want % x", msg, whackStatusMsg)
+	}
+}
+
+func TestExporter_scrapeWhack_BadMagic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ipsec_exporter")
+	if err != nil {
+		panic("failed to create temp dir: " + err.Error())
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "pluto.ctl")
+	// A pluto of a libreswan version with another basic magic
+	_, stop := fakePluto(socket, 0x77686b19+1, nil)
+	defer stop()
+
+	var buf bytes.Buffer
+	exporter, err := New(CollectorWhack, nil, 0, nil, log.NewLogfmtLogger(&buf), WithWhackSocket(socket))
+	if err != nil {
+		t.Fatalf("New() = _, %v; want nil", err)
+	}
+	expected := `
+# HELP ipsec_up Was the last scrape successful.
+# TYPE ipsec_up gauge
+ipsec_up 0
+`
+	if err := testutil.CollectAndCompare(exporter, strings.NewReader(expected)); err != nil {
+		t.Errorf("testutil.CollectAndCompare() = %v; want nil", err)
+	}
+	if !strings.Contains(buf.String(), "Pluto rejected the whack message magic") {
+		t.Errorf("log = %q; want the magic mismatch error", buf.String())
+	}
+}
+
+func TestExporter_scrapeWhack_Timeout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ipsec_exporter")
+	if err != nil {
+		panic("failed to create temp dir: " + err.Error())
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "pluto.ctl")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		panic("failed to listen on " + socket + ": " + err.Error())
+	}
+	defer l.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		<-done
+	}()
+
+	exporter, err := New(CollectorWhack, nil, 100*time.Millisecond, nil, log.NewNopLogger(), WithWhackSocket(socket))
+	if err != nil {
+		t.Fatalf("New() = _, %v; want nil", err)
+	}
+	expected := `
+# HELP ipsec_up Was the last scrape successful.
+# TYPE ipsec_up gauge
+ipsec_up 0
+`
+	if err := testutil.CollectAndCompare(exporter, strings.NewReader(expected)); err != nil {
+		t.Errorf("testutil.CollectAndCompare() = %v; want nil", err)
+	}
+}